@@ -0,0 +1,119 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// An anchor or an alias written in a document
+type anchorUse struct {
+	node     *yaml.Node // node holding the anchor, or the alias
+	anchored *yaml.Node // node of the anchor the alias refers to, the node itself for an anchor, nil for an unknown alias
+}
+
+// Range of the name of an anchor or an alias, without its `&` or `*`
+func (a anchorUse) nameRange() lsp.Range {
+	if a.node.Kind == yaml.AliasNode {
+		return lsp.LineRange(a.node.Line, a.node.Column+1, a.node.Column+1+len(a.node.Value))
+	}
+	return lsp.LineRange(a.node.AnchorLine, a.node.AnchorColumn+1, a.node.AnchorColumn+1+len(a.node.Anchor))
+}
+
+// Anchors and aliases of a document in the order they are written, an alias refers to the last anchor of its name before it
+func anchorUses(root *yaml.Node) []anchorUse {
+	uses := []anchorUse{}
+	anchors := map[string]*yaml.Node{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		switch {
+		case n.Kind == yaml.AliasNode:
+			uses = append(uses, anchorUse{n, anchors[n.Value]})
+		case n.Anchor != "":
+			anchors[n.Anchor] = n
+			uses = append(uses, anchorUse{n, n})
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+	return uses
+}
+
+// Anchor or alias whose name is at a position
+func anchorUseAt(root *yaml.Node, position lsp.Position) (anchorUse, bool) {
+	for _, use := range anchorUses(root) {
+		if inRange(use.nameRange(), position) || (use.node.Kind == yaml.AliasNode && contains(use.node, position)) {
+			return use, true
+		}
+	}
+	return anchorUse{}, false
+}
+
+// Anchor of the alias at a position
+func aliasDefinition(document string, position lsp.Position) (lsp.Range, bool) {
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return lsp.Range{}, false
+	}
+	use, found := anchorUseAt(root, position)
+	if !found || use.anchored == nil || use.node.Kind != yaml.AliasNode {
+		return lsp.Range{}, false
+	}
+	return anchorUse{use.anchored, use.anchored}.nameRange(), true
+}
+
+// Range of the name of the anchor or alias at a position, what a rename would change there
+func prepareRename(document string, position lsp.Position) *lsp.Range {
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return nil
+	}
+	use, found := anchorUseAt(root, position)
+	if !found || use.anchored == nil {
+		return nil
+	}
+	name := use.nameRange()
+	return &name
+}
+
+// Edits renaming the anchor at a position, or the anchor of the alias at a position, and the aliases referring to it
+func renameAnchor(document, uri string, position lsp.Position, name string) (lsp.WorkspaceEdit, error) {
+	if name == "" || strings.ContainsAny(name, " \t,[]{}") {
+		return lsp.WorkspaceEdit{}, fmt.Errorf("`%s` is not a valid anchor name", name)
+	}
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return lsp.WorkspaceEdit{}, err
+	}
+	at, found := anchorUseAt(root, position)
+	if !found || at.anchored == nil {
+		return lsp.WorkspaceEdit{}, errors.New("Only anchors and aliases can be renamed")
+	}
+
+	edits := []lsp.TextEdit{}
+	for _, use := range anchorUses(root) {
+		if use.anchored == at.anchored {
+			edits = append(edits, lsp.TextEdit{Range: use.nameRange(), NewText: name})
+		}
+	}
+	return lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{uri: edits}}, nil
+}
+
+// Aliases to unknown anchors, aliases inside their own anchor and merge keys of something else than mappings
+func diagnoseAliases(root *yaml.Node) []lsp.Diagnostics {
+	_, err := yaml.Expand(root)
+	var expandError *yaml.Error
+	if !errors.As(err, &expandError) {
+		return []lsp.Diagnostics{}
+	}
+	return []lsp.Diagnostics{{
+		Range:    lsp.LineRange(expandError.Line, expandError.Column, expandError.Column),
+		Severity: 1,
+		Source:   "dbwf-ls",
+		Message:  strings.ToUpper(expandError.Message[:1]) + expandError.Message[1:] + ".",
+	}}
+}
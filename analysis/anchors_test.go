@@ -0,0 +1,52 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRenameAnchor(t *testing.T) {
+	document := `job_clusters:
+  - job_cluster_key: small
+    new_cluster: &cluster
+      num_workers: 2
+  - job_cluster_key: big
+    new_cluster:
+      <<: *cluster
+      num_workers: 8
+  - job_cluster_key: other
+    new_cluster: &cluster {num_workers: 1}
+  - job_cluster_key: last
+    new_cluster: *cluster
+`
+	uri := "file:///tmp/anchors.flow.yaml"
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	state.OpenDocument(uri, document, logger)
+
+	// From the alias, aliases after a second anchor of the same name refer to the second one
+	response, err := state.Rename(1, uri, lsp.Position{Line: 6, Character: 12}, "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(document, "cluster\n", "base\n", 2)
+	if actual := applyEdits(t, document, response.Result.Changes[uri]); expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+
+	definition, err := state.Definition(2, uri, lsp.Position{Line: 11, Character: 20}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := lsp.LineRange(9, 18, 25), definition.Result.Range; expected != actual {
+		t.Fatalf("Expected: %v, Actual: %v", expected, actual)
+	}
+
+	if _, err := state.Rename(3, uri, lsp.Position{Line: 1, Character: 22}, "base"); err == nil {
+		t.Fatalf("Expected: an error renaming a task key, Actual: none")
+	}
+}
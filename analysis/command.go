@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// A command of `workspace/executeCommand`, it gets the raw arguments sent by the client
+type command func(s *State, arguments []json.RawMessage, logger *log.Logger) (any, *lsp.WorkspaceEdit, error)
+
+// Commands the server can execute, by name
+var Commands = map[string]command{
+	"dbwf.importJob": importJob,
+	"dbwf.showGraph": showGraph,
+}
+
+// Names of the commands, sorted, for the capabilities of the server
+func CommandNames() []string {
+	names := []string{}
+	for name := range Commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Decode the arguments of a command, missing trailing arguments keep their zero value
+func decodeArguments(arguments []json.RawMessage, targets ...any) error {
+	if len(arguments) > len(targets) {
+		return fmt.Errorf("expects at most %d arguments, got %d", len(targets), len(arguments))
+	}
+	for i, argument := range arguments {
+		if err := json.Unmarshal(argument, targets[i]); err != nil {
+			return fmt.Errorf("argument %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+// `dbwf.importJob`, turn a job JSON into a `.flow.yaml`
+// Arguments are the JSON itself or the path of a file holding it, and optionally the uri of the document to create
+// The document is the result, it is also created when a uri is given
+func importJob(s *State, arguments []json.RawMessage, logger *log.Logger) (any, *lsp.WorkspaceEdit, error) {
+	var source, target string
+	if err := decodeArguments(arguments, &source, &target); err != nil {
+		return nil, nil, err
+	}
+	if source == "" {
+		return nil, nil, errors.New("expects a job JSON or the path to one")
+	}
+
+	data := []byte(source)
+	if !strings.HasPrefix(strings.TrimSpace(source), "{") {
+		content, err := os.ReadFile(strings.TrimPrefix(source, "file://"))
+		if err != nil {
+			return nil, nil, err
+		}
+		data = content
+	}
+	root, err := workflow.Import(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	document := yaml.Encode(root)
+	logger.Printf("Imported a job of %d bytes", len(data))
+
+	if target == "" {
+		return document, nil, nil
+	}
+	edit := &lsp.WorkspaceEdit{
+		DocumentChanges: []any{
+			lsp.CreateFile{Kind: "create", URI: target, Options: &lsp.CreateFileOptions{Overwrite: true}},
+			lsp.TextDocumentEdit{
+				TextDocument: lsp.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: target},
+				},
+				Edits: []lsp.TextEdit{{Range: lsp.LineRange(0, 0, 0), NewText: document}},
+			},
+		},
+	}
+	return document, edit, nil
+}
+
+// `dbwf.showGraph`, the task dependency graph of an open document
+// Arguments are the uri of the document, optionally the format, `mermaid` (default) or `dot`,
+// and whether to highlight the critical path
+func showGraph(s *State, arguments []json.RawMessage, logger *log.Logger) (any, *lsp.WorkspaceEdit, error) {
+	var uri, format string
+	var critical bool
+	if err := decodeArguments(arguments, &uri, &format, &critical); err != nil {
+		return nil, nil, err
+	}
+	document, found := s.Documents[uri]
+	if !found {
+		return nil, nil, fmt.Errorf("%s is not open", uri)
+	}
+
+	root, err := yaml.Parse(document)
+	if err == nil {
+		root, err = yaml.Expand(root)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// The graph is drawn from whatever is valid, the problems are already diagnostics
+	settings, _ := workflow.Settings(root)
+	graph := workflow.NewGraph(settings)
+	switch format {
+	case "", "mermaid":
+		return graph.Mermaid(critical), nil, nil
+	case "dot":
+		return graph.DOT(critical), nil, nil
+	}
+	return nil, nil, fmt.Errorf("unknown format %s, expected mermaid or dot", format)
+}
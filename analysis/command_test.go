@@ -0,0 +1,129 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Raw arguments of a command
+func arguments(values ...any) []json.RawMessage {
+	raw := []json.RawMessage{}
+	for _, value := range values {
+		encoded, _ := json.Marshal(value)
+		raw = append(raw, encoded)
+	}
+	return raw
+}
+
+func TestImportJobCommand(t *testing.T) {
+	job := `{"job_id": 1, "settings": {"name": "report", "tasks": [{"task_key": "a", "notebook_task": {"notebook_path": "/a"}}]}}`
+	file := filepath.Join(t.TempDir(), "job.json")
+	os.WriteFile(file, []byte(job), 0o644)
+	expected := `# Imported from Databricks job 1
+name: report
+tasks:
+  - task_key: a
+    notebook_task:
+      notebook_path: /a
+`
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+
+	// The JSON itself or the path of a file holding it
+	for _, source := range []string{job, file, "file://" + file} {
+		params := lsp.ExecuteCommandParams{Command: "dbwf.importJob", Arguments: arguments(source)}
+		response, edit, err := state.ExecuteCommand(1, params, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected != response.Result {
+			t.Fatalf("Expected: %s, Actual: %v", expected, response.Result)
+		}
+		if edit != nil {
+			t.Fatalf("Expected: no edit without a uri, Actual: %v", edit)
+		}
+	}
+
+	// The document is created at the uri
+	params := lsp.ExecuteCommandParams{Command: "dbwf.importJob", Arguments: arguments(job, "file:///report.flow.yaml")}
+	_, edit, err := state.ExecuteCommand(1, params, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit == nil || len(edit.DocumentChanges) != 2 {
+		t.Fatalf("Expected: a file created and filled, Actual: %v", edit)
+	}
+	if create := edit.DocumentChanges[0].(lsp.CreateFile); create.URI != "file:///report.flow.yaml" {
+		t.Fatalf("Expected: file:///report.flow.yaml, Actual: %s", create.URI)
+	}
+	if text := edit.DocumentChanges[1].(lsp.TextDocumentEdit).Edits[0].NewText; expected != text {
+		t.Fatalf("Expected: %s, Actual: %s", expected, text)
+	}
+
+	for _, invalid := range [][]json.RawMessage{
+		nil,
+		arguments(`{"settings": `),
+		arguments(filepath.Join(t.TempDir(), "missing.json")),
+		arguments(job, "file:///report.flow.yaml", "extra"),
+	} {
+		params := lsp.ExecuteCommandParams{Command: "dbwf.importJob", Arguments: invalid}
+		if _, _, err := state.ExecuteCommand(1, params, logger); err == nil {
+			t.Fatalf("Expected: an error for %s, Actual: none", invalid)
+		}
+	}
+}
+
+func TestShowGraphCommand(t *testing.T) {
+	document := `name: graph
+tasks:
+  - task_key: ingest
+  - task_key: report
+    depends_on:
+      - task_key: ingest
+`
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	state.Documents["file:///graph.flow.yaml"] = document
+
+	tests := []struct {
+		arguments []json.RawMessage
+		expected  string
+	}{
+		{arguments("file:///graph.flow.yaml"), "flowchart TD\n  t0[\"ingest\"]\n  t1[\"report\"]\n  t0 --> t1\n"},
+		{arguments("file:///graph.flow.yaml", "mermaid"), "flowchart TD\n  t0[\"ingest\"]\n  t1[\"report\"]\n  t0 --> t1\n"},
+		{arguments("file:///graph.flow.yaml", "dot"), `digraph workflow {
+  rankdir=TB;
+  node [shape=box];
+  "ingest" [label="ingest"];
+  "report" [label="report"];
+  "ingest" -> "report";
+}
+`},
+	}
+	for _, test := range tests {
+		params := lsp.ExecuteCommandParams{Command: "dbwf.showGraph", Arguments: test.arguments}
+		response, edit, err := state.ExecuteCommand(1, params, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.expected != response.Result || edit != nil {
+			t.Fatalf("Expected: %s, Actual: %v", test.expected, response.Result)
+		}
+	}
+
+	for _, invalid := range [][]json.RawMessage{
+		arguments("file:///graph.flow.yaml", "svg"),
+		arguments("file:///closed.flow.yaml"),
+	} {
+		params := lsp.ExecuteCommandParams{Command: "dbwf.showGraph", Arguments: invalid}
+		if _, _, err := state.ExecuteCommand(1, params, logger); err == nil {
+			t.Fatalf("Expected: an error for %s, Actual: none", invalid)
+		}
+	}
+}
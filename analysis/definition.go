@@ -2,6 +2,8 @@ package analysis
 
 import (
 	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
@@ -12,49 +14,89 @@ type definition struct {
 	defined, lastReferred lsp.Range
 }
 
+// Declaration of the task or job cluster at a position
+// A document that doesn't parse, e.g. in the middle of an edit, is searched line by line
+func findDefinition(document string, position lsp.Position, logger *log.Logger) (lsp.Range, error) {
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return findDefinitionInLines(document, position, logger)
+	}
+	index := indexReferences(root)
+	at, found := index.at(position)
+	if !found {
+		return lsp.Range{}, errors.New("Not task or cluster")
+	}
+	declared, found := index.definition(at.symbol)
+	if !found {
+		return lsp.Range{}, errors.New("Not defined")
+	}
+	return scalarRange(declared.node, 0, len(declared.node.Value)), nil
+}
+
+// Declaration of the task or job cluster at a position, from the text of the lines
+func findDefinitionInLines(document string, position lsp.Position, logger *log.Logger) (lsp.Range, error) {
+	lines := strings.Split(document, "\n")
+	if position.Line >= len(lines) || lines[position.Line] == "" || position.Character > len(lines[position.Line]) {
+		return lsp.Range{}, errors.New("Not a word")
+	}
+	item_name, err := wordAtCursor(lines[position.Line], position, logger)
+	if err != nil {
+		return lsp.Range{}, err
+	}
+	if item_name == "" {
+		return lsp.Range{}, errors.New("Not a word")
+	}
+	if !strings.Contains(lines[position.Line], "job_cluster_key:") && !strings.Contains(lines[position.Line], "task_key:") {
+		return lsp.Range{}, errors.New("Not task or cluster")
+	}
+
+	item := findDefinitionByName(lines, item_name, logger)
+	if item.defined == lsp.LineRange(0, 0, 0) {
+		return lsp.Range{}, errors.New("Not defined")
+	}
+	return item.defined, nil
+}
+
 // Parse the location of the task or cluster definition
 // Cluster expecting a new_cluster and task expecting a description to identify definition
-// Could have done better with yaml to json parsing, but oh well
-func findDefinition(document, item_name string, logger *log.Logger) definition {
+func findDefinitionByName(lines []string, item_name string, logger *log.Logger) definition {
 	item := definition{}
-	re, err := regexp.Compile(fmt.Sprintf("^[\\s-]*(job_cluster_key|task_key):\\s*\"?(%s)\"?\\s*#*.*$", item_name))
+	re, err := regexp.Compile(fmt.Sprintf("^[\\s-]*(job_cluster_key|task_key):\\s*\"?(%s)\"?\\s*(#.*)?$", regexp.QuoteMeta(item_name)))
 	if err != nil {
 		logger.Println(err)
 		return item
 	}
-
-	lines := strings.Split(document, "\n")
-	documentLength := len(lines)
+	next := regexp.MustCompile("^.*(new_cluster|description):\\s*#*.*$")
 
 	for i, line := range lines {
-		matches := re.FindStringSubmatch(line)
-		if matches != nil && len(matches) >= 3 {
-			matchIndex := re.FindStringSubmatchIndex(line)
-			var isNewCluster bool
-			if i == documentLength-1 {
-				isNewCluster = false
-			} else {
-				isNewCluster, err = regexp.MatchString("^.*new_cluster:\\s*#*.*$", lines[i+1])
-				if err != nil {
-					logger.Println(err)
-					return item
-				}
-			}
-			var isTaskDefinition bool
-			if i == documentLength-1 {
-				isTaskDefinition = false
-			} else {
-				isTaskDefinition, err = regexp.MatchString("^.*description:\\s*#*.*$", lines[i+1])
-				if err != nil {
-					logger.Println(err)
-					return item
-				}
-			}
-			if isNewCluster || isTaskDefinition {
-				item.defined = lsp.LineRange(i, matchIndex[4], matchIndex[5])
-			}
+		matchIndex := re.FindStringSubmatchIndex(line)
+		if matchIndex != nil && i+1 < len(lines) && next.MatchString(lines[i+1]) {
+			item.defined = lsp.LineRange(i, matchIndex[4], matchIndex[5])
 		}
 	}
 
 	return item
 }
+
+// Declaration and uses of the task or job cluster at a position,
+// the declaration is written and the uses read
+func documentHighlights(document string, position lsp.Position) []lsp.DocumentHighlight {
+	highlights := []lsp.DocumentHighlight{}
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return highlights
+	}
+	index := indexReferences(root)
+	at, found := index.at(position)
+	if !found {
+		return highlights
+	}
+	for _, r := range index.of(at.symbol) {
+		kind := lsp.ReadHighlight
+		if r.definition {
+			kind = lsp.WriteHighlight
+		}
+		highlights = append(highlights, lsp.DocumentHighlight{Range: scalarRange(r.node, 0, len(r.node.Value)), Kind: kind})
+	}
+	return highlights
+}
@@ -0,0 +1,101 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"io"
+	"log"
+	"slices"
+	"testing"
+)
+
+func TestDefinition(t *testing.T) {
+	document := `name: definitions
+job_clusters:
+  - job_cluster_key: main
+    new_cluster:
+      num_workers: 1
+tasks:
+  - task_key: ingest
+    description: first
+    job_cluster_key: main
+  - task_key: report
+    description: second
+    depends_on:
+      - task_key: ingest
+`
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{"parsed", document},
+		{"being edited", document + "  - task_key: 'unterminated\n"},
+	}
+	logger := log.New(io.Discard, "", 0)
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///definitions.flow.yaml"] = test.document
+		expected := map[lsp.Position]lsp.Range{
+			{Line: 12, Character: 20}: lsp.LineRange(6, 14, 20), // a dependency
+			{Line: 8, Character: 23}:  lsp.LineRange(2, 21, 25), // a job cluster
+		}
+		for position, expected := range expected {
+			response, err := state.Definition(1, "file:///definitions.flow.yaml", position, logger)
+			if err != nil {
+				t.Fatalf("%s, %v: %s", test.name, position, err)
+			}
+			if actual := response.Result.Range; expected != actual {
+				t.Fatalf("%s, Expected: %v, Actual: %v", test.name, expected, actual)
+			}
+		}
+		if _, err := state.Definition(1, "file:///definitions.flow.yaml", lsp.Position{Line: 0, Character: 8}, logger); err == nil {
+			t.Fatalf("%s, Expected: no definition for the name", test.name)
+		}
+	}
+}
+
+func TestDocumentHighlight(t *testing.T) {
+	document := `name: highlights
+job_clusters:
+  - job_cluster_key: main
+    new_cluster:
+      num_workers: 1
+tasks:
+  - task_key: ingest
+    job_cluster_key: main
+  - task_key: report
+    job_cluster_key: main
+    depends_on:
+      - task_key: ingest
+`
+	tests := []struct {
+		position lsp.Position
+		expected []lsp.DocumentHighlight
+	}{
+		// From the declaration of a task or one of its uses
+		{lsp.Position{Line: 6, Character: 16}, []lsp.DocumentHighlight{
+			{Range: lsp.LineRange(6, 14, 20), Kind: lsp.WriteHighlight},
+			{Range: lsp.LineRange(11, 18, 24), Kind: lsp.ReadHighlight},
+		}},
+		{lsp.Position{Line: 11, Character: 20}, []lsp.DocumentHighlight{
+			{Range: lsp.LineRange(6, 14, 20), Kind: lsp.WriteHighlight},
+			{Range: lsp.LineRange(11, 18, 24), Kind: lsp.ReadHighlight},
+		}},
+		// Job cluster, the tasks come first in the index
+		{lsp.Position{Line: 7, Character: 23}, []lsp.DocumentHighlight{
+			{Range: lsp.LineRange(7, 21, 25), Kind: lsp.ReadHighlight},
+			{Range: lsp.LineRange(9, 21, 25), Kind: lsp.ReadHighlight},
+			{Range: lsp.LineRange(2, 21, 25), Kind: lsp.WriteHighlight},
+		}},
+		// Nothing to highlight outside of a key
+		{lsp.Position{Line: 0, Character: 8}, []lsp.DocumentHighlight{}},
+	}
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///highlights.flow.yaml"] = document
+		actual := state.DocumentHighlight(1, "file:///highlights.flow.yaml", test.position).Result
+		if !slices.Equal(test.expected, actual) {
+			t.Fatalf("%v, Expected: %v, Actual: %v", test.position, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"fmt"
+)
+
+// Keys of deprecated fields, with the hint of the schema
+// The client strikes them through thanks to the deprecated tag
+func diagnoseDeprecated(root *yaml.Node) []lsp.Diagnostics {
+	diagnostics := []lsp.Diagnostics{}
+	var walk func(n *yaml.Node, field *workflow.Field)
+	walk = func(n *yaml.Node, field *workflow.Field) {
+		for field != nil && field.Type == workflow.ArrayType {
+			field = field.Items
+		}
+		if field == nil {
+			return
+		}
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item, field)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				var child *workflow.Field
+				switch field.Type {
+				case workflow.MapType:
+					child = field.Values
+				case workflow.ObjectType:
+					child = field.Field(key.Value)
+				}
+				if child != nil && child.Deprecated != "" {
+					diagnostics = append(diagnostics, lsp.Diagnostics{
+						Range:    nodeRange(key),
+						Severity: 2,
+						Source:   "dbwf-ls",
+						Message:  fmt.Sprintf("`%s` is deprecated, %s.", key.Value, child.Deprecated),
+						Tags:     []int{lsp.DeprecatedTag},
+					})
+				}
+				walk(value, child)
+			}
+		}
+	}
+	walk(root, workflow.Job)
+	return diagnostics
+}
@@ -0,0 +1,49 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestDeprecatedDiagnostics(t *testing.T) {
+	document := `name: job
+format: MULTI_TASK
+tasks:
+  - task_key: ingest
+    description: Ingest
+    libraries:
+      - egg: dbfs:/ingest.egg
+`
+	state := analysis.NewState()
+	// Sorted by message like the other diagnostics
+	expected := []lsp.Diagnostics{{
+		Range:    lsp.LineRange(6, 8, 11),
+		Severity: 2,
+		Source:   "dbwf-ls",
+		Message:  "`egg` is deprecated, eggs are not supported from Databricks Runtime 14.0, use `whl` instead.",
+		Tags:     []int{lsp.DeprecatedTag},
+	}, {
+		Range:    lsp.LineRange(1, 0, 6),
+		Severity: 2,
+		Source:   "dbwf-ls",
+		Message:  "`format` is deprecated, the format of a job is always `MULTI_TASK`.",
+		Tags:     []int{lsp.DeprecatedTag},
+	}}
+	actual := []lsp.Diagnostics{}
+	for _, diagnostic := range state.UpdateDocument("file:///x.flow.yaml", document, log.New(io.Discard, "", 0)).Params.Diagnostics {
+		if len(diagnostic.Tags) > 0 {
+			actual = append(actual, diagnostic)
+		}
+	}
+	if len(expected) != len(actual) {
+		t.Fatalf("Expected: %v, Actual: %v", expected, actual)
+	}
+	for i := range expected {
+		if expected[i].Range != actual[i].Range || expected[i].Message != actual[i].Message || expected[i].Severity != actual[i].Severity {
+			t.Fatalf("Expected: %v, Actual: %v", expected[i], actual[i])
+		}
+	}
+}
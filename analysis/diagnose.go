@@ -3,8 +3,12 @@ package analysis
 import (
 	"cmp"
 	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
@@ -19,7 +23,9 @@ type requiredKeys struct {
 // Kind of simple diagnose
 // Some keywords are either required or should have
 // If some tasks or clusters were referenced but not defined, it will also emit errors
-func diagnose(document string, logger *log.Logger) []lsp.Diagnostics {
+// Keys, tasks and clusters of the included files count as written in the document
+// `root` is the parsed document, nil when it doesn't parse
+func diagnose(document string, root *yaml.Node, included []workflow.Included, logger *log.Logger) []lsp.Diagnostics {
 	diagnostics := []lsp.Diagnostics{}
 	required_keywords := map[string]requiredKeys{}
 	for k, v := range Keywords {
@@ -33,6 +39,46 @@ func diagnose(document string, logger *log.Logger) []lsp.Diagnostics {
 	foundJobClusterChunk := false
 	jobClusters := map[string]definition{}
 	tasks := map[string]definition{}
+	// Keys, declarations and uses the lines don't show: through aliases or merge keys, in included files
+	declaredJobClusters := map[string]bool{}
+	declaredTasks := map[string]bool{}
+	usedJobClusters := map[string]bool{}
+	if root != nil {
+		if expanded, err := yaml.Expand(root); err == nil {
+			for k, v := range required_keywords {
+				if expanded.Get(k) != nil {
+					v.found = true
+					required_keywords[k] = v
+				}
+			}
+			for _, r := range indexReferences(expanded) {
+				switch {
+				case r.definition && r.kind == "job_cluster":
+					declaredJobClusters[r.name] = true
+				case r.definition:
+					declaredTasks[r.name] = true
+				case r.kind == "job_cluster":
+					usedJobClusters[r.name] = true
+				}
+			}
+		}
+	}
+	for _, file := range included {
+		for k, v := range required_keywords {
+			if file.Root.Get(k) != nil {
+				v.found = true
+				required_keywords[k] = v
+			}
+		}
+		foundJobClusterChunk = foundJobClusterChunk || file.Root.Get("job_clusters") != nil
+		for _, r := range indexReferences(file.Root) {
+			if r.definition && r.kind == "job_cluster" {
+				declaredJobClusters[r.name] = true
+			} else if r.definition {
+				declaredTasks[r.name] = true
+			}
+		}
+	}
 	re, err := regexp.Compile("^[\\s-]*(job_cluster_key|task_key):\\s*\"?(\\w*)\"?\\s*#*.*$")
 	if err != nil {
 		logger.Println(err)
@@ -141,14 +187,18 @@ func diagnose(document string, logger *log.Logger) []lsp.Diagnostics {
 		})
 	} else {
 		for k, v := range jobClusters {
-			if v.defined != lsp.LineRange(0, 0, 0) && v.lastReferred == lsp.LineRange(0, 0, 0) {
+			if k == "" {
+				// Anchors and aliases, e.g. `job_cluster_key: *main`, are checked from the tree
+				continue
+			}
+			if v.defined != lsp.LineRange(0, 0, 0) && v.lastReferred == lsp.LineRange(0, 0, 0) && !usedJobClusters[k] {
 				diagnostics = append(diagnostics, lsp.Diagnostics{
 					Range:    v.defined,
 					Severity: 2,
 					Source:   "dbwf-ls",
 					Message:  fmt.Sprintf("`%s` is declared but not used anywhere.", k),
 				})
-			} else if v.defined == lsp.LineRange(0, 0, 0) && v.lastReferred != lsp.LineRange(0, 0, 0) {
+			} else if v.defined == lsp.LineRange(0, 0, 0) && v.lastReferred != lsp.LineRange(0, 0, 0) && !declaredJobClusters[k] {
 				diagnostics = append(diagnostics, lsp.Diagnostics{
 					Range:    v.lastReferred,
 					Severity: 1,
@@ -159,7 +209,10 @@ func diagnose(document string, logger *log.Logger) []lsp.Diagnostics {
 		}
 	}
 	for k, v := range tasks {
-		if v.defined == lsp.LineRange(0, 0, 0) && v.lastReferred != lsp.LineRange(0, 0, 0) {
+		if k == "" {
+			continue
+		}
+		if v.defined == lsp.LineRange(0, 0, 0) && v.lastReferred != lsp.LineRange(0, 0, 0) && !declaredTasks[k] {
 			diagnostics = append(diagnostics, lsp.Diagnostics{
 				Range:    v.lastReferred,
 				Severity: 1,
@@ -169,6 +222,14 @@ func diagnose(document string, logger *log.Logger) []lsp.Diagnostics {
 		}
 	}
 
+	// Values that need the structure of the document
+	if root != nil {
+		diagnostics = append(diagnostics, diagnoseSchedule(root)...)
+		diagnostics = append(diagnostics, diagnoseDurations(root)...)
+		diagnostics = append(diagnostics, diagnoseAliases(root)...)
+		diagnostics = append(diagnostics, diagnoseDeprecated(root)...)
+	}
+
 	slices.SortFunc(diagnostics, func(a, b lsp.Diagnostics) int {
 		if cmp.Compare(a.Severity, b.Severity) == 0 {
 			return cmp.Compare(a.Message, b.Message)
@@ -178,3 +239,17 @@ func diagnose(document string, logger *log.Logger) []lsp.Diagnostics {
 
 	return diagnostics
 }
+
+// Diagnostics of a file for callers outside of the language server
+// e.g. the `check` subcommand, so CI enforces the same rules as the editor
+// `file` is the path of the document, included files are read from the disk
+// and `job_name` are looked up in the workflows indexed by the state
+func (s *State) Diagnose(file, document string, logger *log.Logger) []lsp.Diagnostics {
+	if absolute, err := filepath.Abs(file); err == nil {
+		file = absolute
+	}
+	return s.diagnoseDocument(fileURI(file), document, func(path string) (string, error) {
+		content, err := os.ReadFile(path)
+		return string(content), err
+	}, logger)
+}
@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+)
+
+// A node of the document with the keys leading to it
+type located struct {
+	path   []string   // keys from the root, sequence items are skipped
+	key    *yaml.Node // key of the node in its mapping, nil for sequence items and the root
+	node   *yaml.Node
+	parent *yaml.Node // mapping or sequence holding the node, nil for the root
+	onKey  bool       // whether the position is on the key rather than on the node
+}
+
+// Range of a node in the document
+func nodeRange(n *yaml.Node) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: n.Line, Character: n.Column},
+		End:   lsp.Position{Line: n.EndLine, Character: n.EndColumn},
+	}
+}
+
+// Whether a position is inside a node, the end is included for the cursor right after a word
+func contains(n *yaml.Node, position lsp.Position) bool {
+	if position.Line < n.Line || position.Line > n.EndLine {
+		return false
+	}
+	if position.Line == n.Line && position.Character < n.Column {
+		return false
+	}
+	return position.Line != n.EndLine || position.Character <= n.EndColumn
+}
+
+// Innermost node at a position, the root when the position is nowhere in particular
+func locate(root *yaml.Node, position lsp.Position) located {
+	current := located{node: root}
+	for {
+		next, found := current.child(position)
+		if !found {
+			return current
+		}
+		current = next
+	}
+}
+
+func (l located) child(position lsp.Position) (located, bool) {
+	n := l.node
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			path := append(append([]string{}, l.path...), key.Value)
+			if contains(key, position) {
+				return located{path: path, key: key, node: value, parent: n, onKey: true}, true
+			}
+			if contains(value, position) {
+				return located{path: path, key: key, node: value, parent: n}, true
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if contains(item, position) {
+				return located{path: l.path, node: item, parent: n}, true
+			}
+		}
+	}
+	return located{}, false
+}
+
+// Range of a part of a scalar, offsets are in its value
+// Only plain and quoted scalars on a single line are precise, anything else gets the whole node
+func scalarRange(n *yaml.Node, start, end int) lsp.Range {
+	if n.Line != n.EndLine || n.Anchor != "" || n.Tag != "" || (n.Style != yaml.PlainStyle && n.Style != yaml.SingleQuotedStyle && n.Style != yaml.DoubleQuotedStyle) {
+		return nodeRange(n)
+	}
+	column := n.Column
+	if n.Style != yaml.PlainStyle {
+		column++
+	}
+	// Escapes make the value shorter than the source
+	if (n.Style == yaml.PlainStyle && n.EndColumn-n.Column != len(n.Value)) || (n.Style != yaml.PlainStyle && n.EndColumn-n.Column != len(n.Value)+2) {
+		return nodeRange(n)
+	}
+	return lsp.LineRange(n.Line, column+start, column+end)
+}
+
+// Whether two ranges share a line
+func overlaps(a, b lsp.Range) bool {
+	return a.Start.Line <= b.End.Line && b.Start.Line <= a.End.Line
+}
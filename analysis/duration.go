@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Duration held by a numeric value, 0 when the value is not a duration
+// `parent` is the mapping holding the value, the `metric` of a health rule tells what its `value` is
+func durationOf(key *yaml.Node, value *yaml.Node, parent *yaml.Node) time.Duration {
+	if key == nil || value.Kind != yaml.ScalarNode {
+		return 0
+	}
+	amount, ok := yaml.Resolve(value).(int64)
+	if !ok {
+		return 0
+	}
+	metric := ""
+	if node := parent.Get("metric"); node != nil {
+		metric = node.Value
+	}
+	return time.Duration(amount) * workflow.DurationUnit(key.Value, metric)
+}
+
+// Hover of a duration, e.g. `10800` seconds is 3h 0m
+func hoverDuration(key *yaml.Node, value *yaml.Node, duration time.Duration) lsp.MarkupContent {
+	unit := "seconds"
+	switch {
+	case strings.HasSuffix(key.Value, "_millis"):
+		unit = "milliseconds"
+	case strings.HasSuffix(key.Value, "_minutes"):
+		unit = "minutes"
+	}
+	human := workflow.HumanDuration(duration)
+	if duration == 0 && key.Value == "timeout_seconds" {
+		human = "no timeout"
+	}
+	return lsp.MarkupContent{
+		Kind:  "markdown",
+		Value: fmt.Sprintf("`%s` %s = **%s**", value.Value, unit, human),
+	}
+}
+
+// Timeout of the job or of a task, a limit to the duration of what runs inside
+type durationLimit struct {
+	seconds int64
+	owner   string
+}
+
+// Health thresholds on the run duration can't exceed the timeout of the job or of the task,
+// the run is stopped before the alert could fire
+func diagnoseDurations(root *yaml.Node) []lsp.Diagnostics {
+	limits := durationLimits(root, "job", nil)
+	diagnostics := checkDurations(root, limits)
+	if tasks := root.Get("tasks"); tasks != nil && tasks.Kind == yaml.SequenceNode {
+		for _, task := range tasks.Content {
+			diagnostics = append(diagnostics, checkTaskDurations(task, limits)...)
+		}
+	}
+	return diagnostics
+}
+
+// Durations of a task, and of the nested task of a `for_each_task` that runs within it
+func checkTaskDurations(task *yaml.Node, limits []durationLimit) []lsp.Diagnostics {
+	limits = durationLimits(task, "task", limits)
+	diagnostics := checkDurations(task, limits)
+	if nested := task.Get("for_each_task").Get("task"); nested != nil {
+		diagnostics = append(diagnostics, checkTaskDurations(nested, limits)...)
+	}
+	return diagnostics
+}
+
+// Limits of what runs inside the job or a task, its own timeout added to the ones around it
+func durationLimits(owner *yaml.Node, name string, limits []durationLimit) []durationLimit {
+	timeout := owner.Get("timeout_seconds")
+	if timeout == nil {
+		return limits
+	}
+	seconds, ok := yaml.Resolve(timeout).(int64)
+	if !ok || seconds <= 0 {
+		return limits
+	}
+	return append(slices.Clip(limits), durationLimit{seconds: seconds, owner: name})
+}
+
+// Health rules of the job or of a task against the lowest timeout they run within
+func checkDurations(owner *yaml.Node, limits []durationLimit) []lsp.Diagnostics {
+	diagnostics := []lsp.Diagnostics{}
+	rules := owner.Get("health").Get("rules")
+	if rules == nil {
+		return diagnostics
+	}
+	for _, rule := range rules.Content {
+		metric, value := rule.Get("metric"), rule.Get("value")
+		if metric == nil || metric.Value != "RUN_DURATION_SECONDS" || value == nil {
+			continue
+		}
+		threshold, ok := yaml.Resolve(value).(int64)
+		if !ok {
+			continue
+		}
+		var lowest *durationLimit
+		for i, limit := range limits {
+			if limit.seconds < threshold && (lowest == nil || limit.seconds < lowest.seconds) {
+				lowest = &limits[i]
+			}
+		}
+		if lowest == nil {
+			continue
+		}
+		diagnostics = append(diagnostics, lsp.Diagnostics{
+			Range:    nodeRange(value),
+			Severity: 2,
+			Source:   "dbwf-ls",
+			Message: fmt.Sprintf("The health threshold (%s) exceeds the `timeout_seconds` of the %s (%s), this alert can never fire",
+				workflow.HumanDuration(time.Duration(threshold)*time.Second), lowest.owner, workflow.HumanDuration(time.Duration(lowest.seconds)*time.Second)),
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,107 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHealthThresholds(t *testing.T) {
+	document := `name: durations
+timeout_seconds: 3600
+health:
+  rules:
+    - metric: RUN_DURATION_SECONDS
+      op: GREATER_THAN
+      value: 7200
+tasks:
+  - task_key: short
+    timeout_seconds: 600
+    health:
+      rules:
+        - metric: RUN_DURATION_SECONDS
+          op: GREATER_THAN
+          value: 900
+  - task_key: untimed
+    health:
+      rules:
+        - metric: RUN_DURATION_SECONDS
+          op: GREATER_THAN
+          value: 5400
+  - task_key: fine
+    timeout_seconds: 1200
+    health:
+      rules:
+        - metric: RUN_DURATION_SECONDS
+          op: GREATER_THAN
+          value: 1000
+  - task_key: loop
+    timeout_seconds: 1800
+    for_each_task:
+      inputs: "[1, 2]"
+      task:
+        task_key: iteration
+        health:
+          rules:
+            - metric: RUN_DURATION_SECONDS
+              op: GREATER_THAN
+              value: 2400
+`
+	state := analysis.NewState()
+	notification := state.OpenDocument("file:///durations.flow.yaml", document, log.New(io.Discard, "", 0))
+
+	// Sorted by line as text
+	expected := []string{
+		"15:10m 0s task", // a task against its own timeout
+		"21:1h 0m job",   // a task without a timeout against the one of the job
+		"39:30m 0s task", // the nested task against the timeout of its loop
+		"7:1h 0m job",    // the job against its own timeout
+	}
+	actual := []string{}
+	for _, diagnostic := range notification.Params.Diagnostics {
+		message, found := strings.CutPrefix(diagnostic.Message, "The health threshold")
+		if !found {
+			continue
+		}
+		owner := "job"
+		if strings.Contains(message, "of the task") {
+			owner = "task"
+		}
+		limit := message[strings.LastIndex(message, "(")+1 : strings.LastIndex(message, ")")]
+		actual = append(actual, fmt.Sprintf("%d:%s %s", diagnostic.Range.Start.Line+1, limit, owner))
+	}
+	slices.Sort(actual)
+	if !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestDurationHover(t *testing.T) {
+	document := `name: job
+timeout_seconds: 0
+tasks:
+  - task_key: ingest
+    timeout_seconds: 10800
+`
+	tests := map[lsp.Position]string{
+		{Line: 1, Character: 17}: "`0` seconds = **no timeout**",
+		{Line: 4, Character: 22}: "`10800` seconds = **3h 0m**",
+	}
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	state.Documents["file:///x.flow.yaml"] = document
+	for position, expected := range tests {
+		response, err := state.Hover(1, "file:///x.flow.yaml", position, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := response.Result.Contents.Value; expected != actual {
+			t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Notebooks are referred to without their extension
+var notebookExtensions = []string{".py", ".ipynb", ".sql", ".scala", ".r", ".R"}
+
+// Fields holding the path of a file or a folder, by keys from the task
+var fileFields = [][]string{
+	{"notebook_task", "notebook_path"},
+	{"spark_python_task", "python_file"},
+	{"sql_task", "file", "path"},
+	{"dbt_task", "project_directory"},
+	{"libraries", "whl"},
+	{"libraries", "jar"},
+	{"libraries", "requirements"},
+}
+
+// A path written in a document
+type filePath struct {
+	node  *yaml.Node
+	task  *yaml.Node // task the path belongs to
+	field []string   // keys from the task, e.g. `notebook_task`, `notebook_path`
+	git   bool       // whether the path is in the git repository of the job, from `source: GIT` or the `git_source` of the job
+}
+
+// Paths of files in the tasks of a document, nested `for_each_task` tasks included
+func filePaths(root *yaml.Node) []filePath {
+	paths := []filePath{}
+	var task func(n *yaml.Node)
+	task = func(n *yaml.Node) {
+		for _, field := range fileFields {
+			nodes := []*yaml.Node{n}
+			for _, key := range field {
+				next := []*yaml.Node{}
+				for _, node := range nodes {
+					// Libraries are a sequence of mappings
+					if node.Kind == yaml.SequenceNode {
+						for _, item := range node.Content {
+							if value := item.Get(key); value != nil {
+								next = append(next, value)
+							}
+						}
+					} else if value := node.Get(key); value != nil {
+						next = append(next, value)
+					}
+				}
+				nodes = next
+			}
+			// Tasks read their files from the `git_source` of the job unless their `source` says otherwise
+			holder := n
+			for _, key := range field[:len(field)-1] {
+				holder = holder.Get(key)
+			}
+			git := false
+			if holder != nil && holder.Kind == yaml.MappingNode {
+				source := holder.Get("source")
+				git = (source == nil && root.Get("git_source") != nil) || (source != nil && source.Value == "GIT")
+			}
+			for _, node := range nodes {
+				if node.Kind == yaml.ScalarNode && node.Value != "" {
+					paths = append(paths, filePath{node, n, field, git})
+				}
+			}
+		}
+		if nested := n.Get("for_each_task").Get("task"); nested != nil {
+			task(nested)
+		}
+	}
+	if tasks := root.Get("tasks"); tasks != nil && tasks.Kind == yaml.SequenceNode {
+		for _, n := range tasks.Content {
+			task(n)
+		}
+	}
+	return paths
+}
+
+// Local path of a `file://` uri
+func uriPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	return parsed.Path
+}
+
+// `file://` uri of a local path
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// `dbfs:/...`, `s3://...`, every path starting with a scheme
+var scheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// Local files a path of a document may be, in order
+// Relative paths start from the git root for a path in git and from the folder of the document otherwise,
+// absolute paths are only local under a workspace prefix mapped to a local folder, the longest prefix wins.
+// `file:` paths are local too, notebooks may leave out their extension
+// Empty when the path is not meant to be local, e.g. a volume or cloud storage path
+func localCandidates(documentURI string, path filePath, workspacePaths map[string]string) []string {
+	value := path.node.Value
+	switch {
+	case strings.Contains(value, "{{") || strings.ContainsAny(value, "*?["):
+		// Dynamic values and patterns are only known when the job runs
+		return []string{}
+	case strings.HasPrefix(value, "file://"):
+		value = uriPath(value)
+	case strings.HasPrefix(value, "file:"):
+		value = strings.TrimPrefix(value, "file:")
+	case scheme.MatchString(value):
+		return []string{}
+	case strings.HasPrefix(value, "/"):
+		prefix := ""
+		for workspace := range workspacePaths {
+			trimmed := strings.TrimSuffix(workspace, "/")
+			if (value == trimmed || strings.HasPrefix(value, trimmed+"/")) && len(trimmed) >= len(prefix) {
+				prefix = workspace
+			}
+		}
+		if prefix == "" {
+			return []string{}
+		}
+		value = filepath.Join(workspacePaths[prefix], strings.TrimPrefix(value, strings.TrimSuffix(prefix, "/")))
+	}
+	if !filepath.IsAbs(value) {
+		folder := filepath.Dir(uriPath(documentURI))
+		if path.git {
+			folder = gitRoot(folder)
+		}
+		value = filepath.Join(folder, value)
+	}
+
+	candidates := []string{value}
+	if slices.Equal(path.field, fileFields[0]) {
+		for _, extension := range notebookExtensions {
+			candidates = append(candidates, value+extension)
+		}
+	}
+	return candidates
+}
+
+// Root of the git repository holding a folder, the folder itself outside of a repository
+func gitRoot(folder string) string {
+	for dir := folder; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return folder
+		}
+	}
+}
+
+// Local file a path of a document refers to, empty when there is no such file
+func localFile(documentURI string, path filePath, workspacePaths map[string]string) string {
+	for _, candidate := range localCandidates(documentURI, path, workspacePaths) {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Warnings for the local paths of a document that don't exist
+// Wheels and jars are left out, they are usually built before the job is deployed
+func diagnoseFiles(root *yaml.Node, uri string, workspacePaths map[string]string) []lsp.Diagnostics {
+	diagnostics := []lsp.Diagnostics{}
+	for _, path := range filePaths(root) {
+		if slices.Equal(path.field, []string{"libraries", "whl"}) || slices.Equal(path.field, []string{"libraries", "jar"}) {
+			continue
+		}
+		candidates := localCandidates(uri, path, workspacePaths)
+		if len(candidates) == 0 || localFile(uri, path, workspacePaths) != "" {
+			continue
+		}
+		diagnostics = append(diagnostics, lsp.Diagnostics{
+			Range:    scalarRange(path.node, 0, len(path.node.Value)),
+			Severity: 2,
+			Source:   "dbwf-ls",
+			Message:  fmt.Sprintf("`%s` doesn't exist locally, looked for `%s`.", path.node.Value, candidates[0]),
+		})
+	}
+	return diagnostics
+}
+
+// `[See more](https://docs.databricks.com/...)` in the hover of a keyword
+var seeMore = regexp.MustCompile(`\[See more\]\((https://[^)]+)\)`)
+
+// Links of a document: paths to the local files they refer to and keys to their documentation
+func documentLinks(root *yaml.Node, uri string, workspacePaths map[string]string) []lsp.DocumentLink {
+	links := []lsp.DocumentLink{}
+	for _, path := range filePaths(root) {
+		if local := localFile(uri, path, workspacePaths); local != "" {
+			links = append(links, lsp.DocumentLink{
+				Range:   scalarRange(path.node, 0, len(path.node.Value)),
+				Target:  fileURI(local),
+				Tooltip: local,
+			})
+		}
+	}
+
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := n.Content[i]
+				if match := seeMore.FindStringSubmatch(Keywords[key.Value].hover.Value); match != nil {
+					links = append(links, lsp.DocumentLink{Range: nodeRange(key), Target: match[1], Tooltip: "Databricks documentation"})
+				}
+			}
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+	return links
+}
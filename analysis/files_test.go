@@ -0,0 +1,142 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckFiles(t *testing.T) {
+	repository := t.TempDir()
+	for _, file := range []string{".git/HEAD", "src/ingest.py", "notebooks/report.ipynb", "jobs/requirements.txt", "checkout/src/clean.py"} {
+		os.MkdirAll(filepath.Join(repository, filepath.Dir(file)), 0o755)
+		os.WriteFile(filepath.Join(repository, file), []byte{}, 0o644)
+	}
+	document := `name: files
+git_source:
+  git_url: https://github.com/me/project
+  git_provider: gitHub
+tasks:
+  - task_key: ingest
+    spark_python_task:
+      python_file: src/ingest.py
+  - task_key: report
+    notebook_task:
+      notebook_path: notebooks/report
+  - task_key: typo
+    spark_python_task:
+      python_file: src/ingets.py
+  - task_key: local
+    notebook_task:
+      notebook_path: ../notebooks/report
+      source: WORKSPACE
+    libraries:
+      - requirements: requirements.txt
+      - requirements: /Volumes/main/default/requirements.txt
+      - whl: ../dist/*.whl
+  - task_key: mapped
+    spark_python_task:
+      python_file: /Workspace/Repos/me/project/src/clean.py
+      source: WORKSPACE
+  - task_key: missing
+    spark_python_task:
+      python_file: /Workspace/Repos/me/project/src/missing.py
+      source: WORKSPACE
+`
+	uri := (&url.URL{Scheme: "file", Path: filepath.Join(repository, "jobs", "a.flow.yaml")}).String()
+	state := analysis.NewState()
+	state.Options.CheckFiles = true
+	state.Options.WorkspacePaths = map[string]string{
+		"/Workspace/Repos/me":          filepath.Join(repository, ".."),
+		"/Workspace/Repos/me/project/": filepath.Join(repository, "checkout"),
+	}
+	notification := state.OpenDocument(uri, document, log.New(io.Discard, "", 0))
+
+	expected := []string{"src/ingets.py", "/Workspace/Repos/me/project/src/missing.py"}
+	actual := []string{}
+	for _, diagnostic := range notification.Params.Diagnostics {
+		if strings.Contains(diagnostic.Message, "doesn't exist locally") {
+			actual = append(actual, document[offset(document, diagnostic.Range.Start):offset(document, diagnostic.Range.End)])
+		}
+	}
+	if !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+
+	state.Options.CheckFiles = false
+	for _, diagnostic := range state.UpdateDocument(uri, document, log.New(io.Discard, "", 0)).Params.Diagnostics {
+		if strings.Contains(diagnostic.Message, "doesn't exist locally") {
+			t.Fatalf("Expected: no check of the files, Actual: %s", diagnostic.Message)
+		}
+	}
+}
+
+func TestDocumentLink(t *testing.T) {
+	folder := t.TempDir()
+	for _, file := range []string{"jobs/src/ingest.py", "jobs/notebooks/report.ipynb", "checkout/src/clean.py"} {
+		os.MkdirAll(filepath.Join(folder, filepath.Dir(file)), 0o755)
+		os.WriteFile(filepath.Join(folder, file), []byte{}, 0o644)
+	}
+	document := `name: links
+tasks:
+  - task_key: relative
+    spark_python_task:
+      python_file: src/ingest.py
+  - task_key: notebook
+    notebook_task:
+      notebook_path: ./notebooks/report
+  - task_key: mapped
+    spark_python_task:
+      python_file: /Workspace/Repos/me/project/src/clean.py
+  - task_key: unmapped
+    spark_python_task:
+      python_file: /Workspace/Shared/clean.py
+  - task_key: missing
+    spark_python_task:
+      python_file: src/missing.py
+  - task_key: remote
+    spark_python_task:
+      python_file: dbfs:/jobs/src/ingest.py
+  - task_key: dynamic
+    spark_python_task:
+      python_file: "src/{{job.parameters.name}}.py"
+`
+	uri := (&url.URL{Scheme: "file", Path: filepath.Join(folder, "jobs", "a.flow.yaml")}).String()
+	state := analysis.NewState()
+	state.Options.WorkspacePaths = map[string]string{"/Workspace/Repos/me/project": filepath.Join(folder, "checkout")}
+	state.Documents[uri] = document
+
+	// Linked text and the file it opens, the keys link to the documentation
+	expected := []string{
+		"src/ingest.py jobs/src/ingest.py",
+		"./notebooks/report jobs/notebooks/report.ipynb",
+		"/Workspace/Repos/me/project/src/clean.py checkout/src/clean.py",
+	}
+	actual := []string{}
+	for _, link := range state.DocumentLink(1, uri).Result {
+		if strings.HasPrefix(link.Target, "file://") {
+			target, _ := filepath.Rel(folder, link.Tooltip)
+			actual = append(actual, document[offset(document, link.Range.Start):offset(document, link.Range.End)]+" "+target)
+		}
+	}
+	if !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+// Offset of a position in a document
+func offset(document string, position lsp.Position) int {
+	lines := strings.SplitAfter(document, "\n")
+	start := 0
+	for _, line := range lines[:position.Line] {
+		start += len(line)
+	}
+	return start + position.Character
+}
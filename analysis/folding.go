@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+	"strings"
+)
+
+// Keys naming the items of a sequence, shown when the item is folded
+var itemNames = []string{"task_key", "job_cluster_key", "environment_key", "user_name", "group_name", "service_principal_name"}
+
+// Folds of a document: every key and sequence item whose value spans several lines,
+// e.g. a task, a `new_cluster` or a multi-line string, and the blocks of comment lines
+func foldingRanges(document string) []lsp.FoldingRange {
+	ranges := []lsp.FoldingRange{}
+
+	// Consecutive comment lines
+	lines := strings.Split(document, "\n")
+	start := -1
+	for row := 0; row <= len(lines); row++ {
+		if row < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[row]), "#") {
+			if start < 0 {
+				start = row
+			}
+			continue
+		}
+		if start >= 0 && row-1 > start {
+			ranges = append(ranges, lsp.FoldingRange{StartLine: start, EndLine: row - 1, Kind: "comment"})
+		}
+		start = -1
+	}
+
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return ranges
+	}
+	var fold func(n *yaml.Node)
+	fold = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				if value.EndLine > key.Line {
+					ranges = append(ranges, lsp.FoldingRange{StartLine: key.Line, EndLine: value.EndLine})
+				}
+				fold(value)
+			}
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				if item.EndLine > item.Line {
+					folded := lsp.FoldingRange{StartLine: item.Line, EndLine: item.EndLine}
+					for _, key := range itemNames {
+						if name := item.Get(key); name != nil && name.Kind == yaml.ScalarNode {
+							folded.CollapsedText = key + ": " + name.Value
+							break
+						}
+					}
+					ranges = append(ranges, folded)
+				}
+				fold(item)
+			}
+		}
+	}
+	fold(root)
+	return ranges
+}
@@ -0,0 +1,68 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFoldingRange(t *testing.T) {
+	tests := []struct {
+		document string
+		expected []string // start and end line of each fold, with its kind and collapsed text
+	}{
+		// Nested mappings
+		{"name: job\nschedule:\n  quartz_cron_expression: 0 0 6 * * ?\n  timezone_id: UTC\ntags:\n  team: data\n", []string{
+			"1-3",
+			"4-5",
+		}},
+		{"job_clusters:\n  - job_cluster_key: main\n    new_cluster:\n      num_workers: 2\n      node_type_id: i3.xlarge\n", []string{
+			"0-4",
+			"1-4 job_cluster_key: main",
+			"2-4",
+		}},
+		// Sequences, items are named after their key
+		{"tasks:\n  - task_key: ingest\n    description: Ingest\n  - task_key: report\n    depends_on:\n      - task_key: ingest\n", []string{
+			"0-5",
+			"1-2 task_key: ingest",
+			"3-5 task_key: report",
+			"4-5",
+		}},
+		{"parameters:\n  - a\n  - b\n", []string{
+			"0-2",
+		}},
+		// Block scalars
+		{"description: |\n  First line\n  Second line\nname: job\n", []string{
+			"0-2",
+		}},
+		{"description: >-\n  Folded\n\n  text\n", []string{
+			"0-3",
+		}},
+		// Comment blocks, a single comment line doesn't fold
+		{"# Nightly job\n# owned by data\nname: job # inline\n# alone\ntags:\n  # team\n  # cost\n  team: data\n", []string{
+			"0-1 comment",
+			"5-6 comment",
+			"4-7",
+		}},
+		// Only the comments fold while the document doesn't parse
+		{"# one\n# two\ntasks:\n  - task_key: [\n", []string{
+			"0-1 comment",
+		}},
+	}
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///folds.flow.yaml"] = test.document
+		actual := []string{}
+		for _, folded := range state.FoldingRange(1, "file:///folds.flow.yaml").Result {
+			described := fmt.Sprintf("%d-%d", folded.StartLine, folded.EndLine)
+			if text := strings.TrimSpace(folded.Kind + " " + folded.CollapsedText); text != "" {
+				described += " " + text
+			}
+			actual = append(actual, described)
+		}
+		if strings.Join(test.expected, "\n") != strings.Join(actual, "\n") {
+			t.Fatalf("%s, Expected: %s, Actual: %s", test.document, test.expected, actual)
+		}
+	}
+}
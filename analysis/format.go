@@ -0,0 +1,240 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// `key: |`, `key: >-`... the next lines are the content of a block scalar
+var blockScalarHeader = regexp.MustCompile(`:\s+[|>][-+0-9]*$`)
+
+// Indentation expected on a new line, from the last line before it that isn't empty
+// `item` tells that the line continues a sequence of scalars and should start with `- `
+// This is what typing in an editor needs, e.g. after `key:` the next line is nested
+func indentation(lines []string, row int) (indent int, item bool) {
+	previous := ""
+	for i := row - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			previous = lines[i]
+			break
+		}
+	}
+	content := strings.TrimLeft(previous, " ")
+	indent = len(previous) - len(content)
+	if comment := strings.Index(content, " #"); comment >= 0 {
+		content = content[:comment]
+	}
+	content = strings.TrimRight(content, " ")
+	if strings.HasPrefix(content, "#") {
+		return indent, false
+	}
+
+	// Content of the innermost sequence item, e.g. `task_key: a` in `- - task_key: a`
+	column, dashes := indent, 0
+	for content == "-" || strings.HasPrefix(content, "- ") {
+		rest := strings.TrimLeft(content[1:], " ")
+		column += max(len(content)-len(rest), 2)
+		content = rest
+		dashes++
+	}
+
+	switch {
+	case content == "":
+		return column, false
+	case strings.HasSuffix(content, ":") || blockScalarHeader.MatchString(content):
+		return column + 2, false
+	case dashes > 0 && !strings.Contains(content, ": "):
+		return column - 2, true
+	}
+	return column, false
+}
+
+// Edit of the indentation of the line typed after a new line
+func formatOnType(document string, position lsp.Position, ch string) []lsp.TextEdit {
+	lines := strings.Split(document, "\n")
+	if ch != "\n" || position.Line >= len(lines) || position.Line == 0 {
+		return []lsp.TextEdit{}
+	}
+	line := lines[position.Line]
+	leading := len(line) - len(strings.TrimLeft(line, " \t"))
+
+	indent, item := indentation(lines, position.Line)
+	text := strings.Repeat(" ", indent)
+	if item && leading == len(line) {
+		text += "- "
+	}
+	if text == line[:leading] {
+		return []lsp.TextEdit{}
+	}
+	return []lsp.TextEdit{{Range: lsp.LineRange(position.Line, 0, leading), NewText: text}}
+}
+
+// Edit of the lines of a selection, formatted as a fragment at the indentation of its first line
+// Selections that don't parse on their own are left alone
+func formatRange(document string, selection lsp.Range) ([]lsp.TextEdit, error) {
+	lines := strings.Split(document, "\n")
+	first, last := selection.Start.Line, min(selection.End.Line, len(lines)-1)
+	if selection.End.Character == 0 && last > first {
+		last--
+	}
+	for first <= last && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	for last >= first && strings.TrimSpace(lines[last]) == "" {
+		last--
+	}
+	if first > last {
+		return []lsp.TextEdit{}, nil
+	}
+
+	block := lines[first : last+1]
+	target := len(block[0]) - len(strings.TrimLeft(block[0], " "))
+	base := target
+	for _, line := range block {
+		if content := strings.TrimLeft(line, " "); content != "" {
+			base = min(base, len(line)-len(content))
+		}
+	}
+	dedented := []string{}
+	for _, line := range block {
+		dedented = append(dedented, line[min(base, len(line)):])
+	}
+	formatted, err := workflow.FormatFragment(strings.Join(dedented, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	indented := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(formatted, "\n"), "\n") {
+		if line != "" {
+			line = strings.Repeat(" ", target) + line
+		}
+		indented = append(indented, line)
+	}
+	formattedLines := append(slices.Clone(lines[:first]), indented...)
+	return textEdits(document, strings.Join(append(formattedLines, lines[last+1:]...), "\n")), nil
+}
+
+// Formatting options on top of a formatted document, `original` is the document before formatting
+// Options left out by the client are up to the formatter, which indents with spaces and ends with a single new line.
+// Options turned off keep the end of the original document as it was
+func applyFormattingOptions(text, original string, opts lsp.FormattingOptions) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if opts.InsertSpaces == nil || *opts.InsertSpaces {
+			content := strings.TrimLeft(line, " \t")
+			indent := line[:len(line)-len(content)]
+			line = strings.ReplaceAll(indent, "\t", strings.Repeat(" ", opts.TabSize)) + content
+		}
+		if opts.TrimTrailingWhitespace != nil && *opts.TrimTrailingWhitespace {
+			line = strings.TrimRight(line, " \t")
+		}
+		lines[i] = line
+	}
+	text = strings.Join(lines, "\n")
+
+	body := strings.TrimRight(text, "\n")
+	newlines := len(text) - len(body)
+	originalNewlines := len(original) - len(strings.TrimRight(original, "\n"))
+	if opts.TrimFinalNewlines != nil && !*opts.TrimFinalNewlines {
+		newlines = max(newlines, originalNewlines)
+	}
+	if opts.InsertFinalNewline != nil && !*opts.InsertFinalNewline && originalNewlines == 0 {
+		newlines = 0
+	}
+	if opts.TrimFinalNewlines != nil && *opts.TrimFinalNewlines {
+		newlines = min(newlines, 1)
+	}
+	if opts.InsertFinalNewline != nil && *opts.InsertFinalNewline && body != "" {
+		newlines = max(newlines, 1)
+	}
+	return body + strings.Repeat("\n", newlines)
+}
+
+// Largest number of pairs of lines compared to find the changes, above it they are replaced at once
+const maxDiff = 1 << 22
+
+// Edits turning a document into another, one per block of changed lines and narrowed to the characters that differ
+// Edits don't overlap and are sorted by position
+func textEdits(before, after string) []lsp.TextEdit {
+	a, b := strings.SplitAfter(before, "\n"), strings.SplitAfter(after, "\n")
+
+	// Common lines at both ends, then the longest common subsequence of the rest
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	matches := [][2]int{} // pairs of equal lines in x and y, in order
+	if len(x)*len(y) <= maxDiff {
+		lengths := make([][]int, len(x)+1)
+		for i := range lengths {
+			lengths[i] = make([]int, len(y)+1)
+		}
+		for i := len(x) - 1; i >= 0; i-- {
+			for j := len(y) - 1; j >= 0; j-- {
+				if x[i] == y[j] {
+					lengths[i][j] = lengths[i+1][j+1] + 1
+				} else {
+					lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+				}
+			}
+		}
+		for i, j := 0, 0; i < len(x) && j < len(y); {
+			switch {
+			case x[i] == y[j]:
+				matches = append(matches, [2]int{i, j})
+				i, j = i+1, j+1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				i++
+			default:
+				j++
+			}
+		}
+	}
+
+	edits := []lsp.TextEdit{}
+	i, j := 0, 0
+	for _, match := range append(matches, [2]int{len(x), len(y)}) {
+		if match[0] > i || match[1] > j {
+			edits = append(edits, narrowEdit(prefix+i, strings.Join(x[i:match[0]], ""), strings.Join(y[j:match[1]], "")))
+		}
+		i, j = match[0]+1, match[1]+1
+	}
+	return edits
+}
+
+// Edit replacing a text starting at the beginning of a line, without the characters both sides share
+func narrowEdit(line int, before, after string) lsp.TextEdit {
+	start := 0
+	for start < len(before) && start < len(after) && before[start] == after[start] {
+		start++
+	}
+	end := 0
+	for end < len(before)-start && end < len(after)-start && before[len(before)-1-end] == after[len(after)-1-end] {
+		end++
+	}
+	// Multi-byte characters are replaced whole
+	for start > 0 && start < len(before) && !utf8.RuneStart(before[start]) {
+		start--
+	}
+	for end > 0 && !utf8.RuneStart(before[len(before)-end]) {
+		end--
+	}
+	position := func(offset int) lsp.Position {
+		lines := strings.Split(before[:offset], "\n")
+		return lsp.Position{Line: line + len(lines) - 1, Character: len(lines[len(lines)-1])}
+	}
+	return lsp.TextEdit{
+		Range:   lsp.Range{Start: position(start), End: position(len(before) - end)},
+		NewText: after[start : len(after)-end],
+	}
+}
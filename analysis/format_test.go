@@ -0,0 +1,151 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+// Document once the edits are applied, they must be sorted and not overlap
+func applyEdits(t *testing.T, document string, edits []lsp.TextEdit) string {
+	lines := strings.SplitAfter(document, "\n")
+	offset := func(position lsp.Position) int {
+		start := 0
+		for _, line := range lines[:position.Line] {
+			start += len(line)
+		}
+		return start + position.Character
+	}
+	result, previous := "", 0
+	for _, edit := range edits {
+		start, end := offset(edit.Range.Start), offset(edit.Range.End)
+		if start < previous || end < start {
+			t.Fatalf("Expected: sorted edits that don't overlap, Actual: %v", edits)
+		}
+		result += document[previous:start] + edit.NewText
+		previous = end
+	}
+	return result + document[previous:]
+}
+
+func TestDocumentFormatting(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name     string
+		document string
+		options  lsp.FormattingOptions
+		expected string
+		edits    int
+	}{
+		{"formatted", "name: a\n", lsp.FormattingOptions{TabSize: 2}, "name: a\n", 0},
+		{"indentation", "name: a\ntags:\n    team: b\n    cost: c\n", lsp.FormattingOptions{TabSize: 2}, "name: a\ntags:\n  team: b\n  cost: c\n", 1},
+		{"insert final newline", "name: a", lsp.FormattingOptions{TabSize: 2, InsertFinalNewline: &yes}, "name: a\n", 1},
+		{"no final newline", "name: a", lsp.FormattingOptions{TabSize: 2, InsertFinalNewline: &no}, "name: a", 0},
+		{"trim final newlines", "name: a\n\n\n", lsp.FormattingOptions{TabSize: 2, TrimFinalNewlines: &yes}, "name: a\n", 1},
+		{"keep final newlines", "name: a\n\n\n", lsp.FormattingOptions{TabSize: 2, TrimFinalNewlines: &no}, "name: a\n\n\n", 0},
+		{"invalid document", "key: 'a\nx:   \n  \n\n", lsp.FormattingOptions{TabSize: 2, TrimTrailingWhitespace: &yes, TrimFinalNewlines: &yes}, "key: 'a\nx:\n", 1},
+		{"invalid document untouched", "key: 'a\nx:   \n  \n\n", lsp.FormattingOptions{TabSize: 2}, "key: 'a\nx:   \n  \n\n", 0},
+		{"tabs", "key: 'a\n\tx: 1\n", lsp.FormattingOptions{TabSize: 4, InsertSpaces: &yes}, "key: 'a\n    x: 1\n", 1},
+		{"tabs kept", "key: 'a\n\tx: 1\n", lsp.FormattingOptions{TabSize: 4, InsertSpaces: &no}, "key: 'a\n\tx: 1\n", 0},
+		{"multi-byte", "name: \"é\"\ndescription: d\ntags:\n  a: 'è'\n", lsp.FormattingOptions{TabSize: 2}, "name: é\ndescription: d\ntags:\n  a: è\n", 2},
+	}
+
+	logger := log.New(io.Discard, "", 0)
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///test.flow.yaml"] = test.document
+		response, err := state.DocumentFormatting(1, "file:///test.flow.yaml", test.options, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := applyEdits(t, test.document, response.Result); test.expected != actual {
+			t.Fatalf("%s, Expected: %q, Actual: %q", test.name, test.expected, actual)
+		}
+		if len(response.Result) != test.edits {
+			t.Fatalf("%s, Expected: %d edits, Actual: %v", test.name, test.edits, response.Result)
+		}
+	}
+}
+
+func TestDocumentRangeFormatting(t *testing.T) {
+	document := `name: "a"
+tasks:
+  - task_key: 'b'
+    spark_python_task:
+      parameters: ["a,b", "x]", 'c', "a: b", "@d", "2024-01-01"]
+tags:
+  team: 'e'
+`
+	expected := `name: "a"
+tasks:
+  - task_key: b
+    spark_python_task:
+      parameters: ["a,b", "x]", c, "a: b", "@d", "2024-01-01"]
+tags:
+  team: 'e'
+`
+	selection := lsp.Range{Start: lsp.Position{Line: 2, Character: 0}, End: lsp.Position{Line: 5, Character: 0}}
+
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	state.Documents["file:///test.flow.yaml"] = document
+	response, err := state.DocumentRangeFormatting(1, "file:///test.flow.yaml", selection, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := applyEdits(t, document, response.Result)
+	if expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+
+	state.Documents["file:///test.flow.yaml"] = actual
+	response, err = state.DocumentRangeFormatting(1, "file:///test.flow.yaml", selection, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if twice := applyEdits(t, actual, response.Result); actual != twice {
+		t.Fatalf("Expected: %s, Actual: %s", actual, twice)
+	}
+}
+
+func TestDocumentOnTypeFormatting(t *testing.T) {
+	tests := []struct {
+		document string // the last line is the one just typed
+		expected string
+	}{
+		// Nested under a key
+		{"tasks:\n", "tasks:\n  "},
+		{"tasks: # comment\n", "tasks: # comment\n  "},
+		{"tasks:\n  - task_key: a\n    notebook_task:\n", "tasks:\n  - task_key: a\n    notebook_task:\n      "},
+		// Next to the keys of an item
+		{"tasks:\n  - task_key: a\n", "tasks:\n  - task_key: a\n    "},
+		{"tasks:\n  -\n", "tasks:\n  -\n    "},
+		// Continuing a list of scalars
+		{"parameters:\n  - a\n", "parameters:\n  - a\n  - "},
+		{"parameters:\n  - a\n  ", "parameters:\n  - a\n  - "},
+		// Block scalars
+		{"description: |\n", "description: |\n  "},
+		{"tasks:\n  - description: >-\n", "tasks:\n  - description: >-\n      "},
+		// Already indented
+		{"tasks:\n  ", "tasks:\n  "},
+		{"name: job\n", "name: job\n"},
+	}
+	state := analysis.NewState()
+	for _, test := range tests {
+		state.Documents["file:///test.flow.yaml"] = test.document
+		position := lsp.Position{Line: strings.Count(test.document, "\n"), Character: 0}
+		response := state.DocumentOnTypeFormatting(1, "file:///test.flow.yaml", position, "\n")
+		if actual := applyEdits(t, test.document, response.Result); test.expected != actual {
+			t.Fatalf("Expected: %q, Actual: %q", test.expected, actual)
+		}
+	}
+
+	// Other characters leave the line alone
+	state.Documents["file:///test.flow.yaml"] = "tasks:\n"
+	if edits := state.DocumentOnTypeFormatting(1, "file:///test.flow.yaml", lsp.Position{Line: 1}, ":").Result; len(edits) != 0 {
+		t.Fatalf("Expected: no edits, Actual: %v", edits)
+	}
+}
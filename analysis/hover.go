@@ -0,0 +1,27 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+	"slices"
+)
+
+// Hover of the value under the cursor, when the value means more than its keyword
+// Nothing is found when the document doesn't parse
+func hoverValue(document string, position lsp.Position) (lsp.MarkupContent, bool) {
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return lsp.MarkupContent{}, false
+	}
+	at := locate(root, position)
+
+	switch {
+	case slices.Equal(at.path, []string{"schedule", "quartz_cron_expression"}) && at.node.Kind == yaml.ScalarNode:
+		return hoverSchedule(at.node, at.parent), true
+	case !at.onKey && at.key != nil && durationOf(at.key, at.node, at.parent) > 0:
+		return hoverDuration(at.key, at.node, durationOf(at.key, at.node, at.parent)), true
+	case !at.onKey && at.key != nil && at.key.Value == "timeout_seconds" && at.node.Value == "0":
+		return hoverDuration(at.key, at.node, 0), true
+	}
+	return lsp.MarkupContent{}, false
+}
@@ -0,0 +1,110 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Files included by a parsed document, and the problems of its `include` as errors
+// A document that doesn't parse, a nil root, includes nothing
+func includes(root *yaml.Node, uri string, read func(path string) (string, error)) ([]workflow.Included, []lsp.Diagnostics) {
+	diagnostics := []lsp.Diagnostics{}
+	if root == nil {
+		return nil, diagnostics
+	}
+	_, included, problems := workflow.ResolveIncludes(root, uriPath(uri), read)
+	for _, problem := range problems {
+		diagnostics = append(diagnostics, lsp.Diagnostics{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: problem.Line, Character: problem.Column},
+				End:   lsp.Position{Line: problem.EndLine, Character: problem.EndColumn},
+			},
+			Severity: 1,
+			Source:   "dbwf-ls",
+			Message:  strings.ToUpper(problem.Message[:1]) + problem.Message[1:] + ".",
+		})
+	}
+	return included, diagnostics
+}
+
+// Content of a file, from the editor when it is open there
+func (s *State) readFile(path string) (string, error) {
+	for uri, document := range s.Documents {
+		if uriPath(uri) == path {
+			return document, nil
+		}
+	}
+	content, err := os.ReadFile(path)
+	return string(content), err
+}
+
+// Included file of the path at a position in the `include` of a document
+func includeAt(included []workflow.Included, position lsp.Position) (workflow.Included, bool) {
+	for _, file := range included {
+		if contains(file.From, position) {
+			return file, true
+		}
+	}
+	return workflow.Included{}, false
+}
+
+// Where the path of an `include` or a task or job cluster declared in an included file is
+func (s *State) includeDefinition(uri string, position lsp.Position) (lsp.Location, bool) {
+	root, err := yaml.Parse(s.Documents[uri])
+	if err != nil {
+		return lsp.Location{}, false
+	}
+	included, _ := includes(root, uri, s.readFile)
+	if file, found := includeAt(included, position); found {
+		return lsp.Location{URI: fileURI(file.File), Range: lsp.LineRange(0, 0, 0)}, true
+	}
+	at, found := indexReferences(root).at(position)
+	if !found {
+		return lsp.Location{}, false
+	}
+	for _, file := range included {
+		if declared, found := indexReferences(file.Root).definition(at.symbol); found {
+			return lsp.Location{URI: fileURI(file.File), Range: scalarRange(declared.node, 0, len(declared.node.Value))}, true
+		}
+	}
+	return lsp.Location{}, false
+}
+
+// Hover of a path of an `include`: the keys the file brings, with the files it includes itself
+func (s *State) hoverInclude(uri string, position lsp.Position) (lsp.MarkupContent, bool) {
+	root, err := yaml.Parse(s.Documents[uri])
+	if err != nil {
+		return lsp.MarkupContent{}, false
+	}
+	included, _ := includes(root, uri, s.readFile)
+	file, found := includeAt(included, position)
+	if !found {
+		return lsp.MarkupContent{}, false
+	}
+	lines := []string{fmt.Sprintf("`%s`", file.File), ""}
+	for _, other := range included {
+		if other.From != file.From {
+			continue
+		}
+		from := ""
+		if other.File != file.File {
+			from = fmt.Sprintf(" from `%s`", filepath.Base(other.File))
+		}
+		for i := 0; i+1 < len(other.Root.Content); i += 2 {
+			key, value := other.Root.Content[i], other.Root.Content[i+1]
+			switch {
+			case key.Value == "include":
+			case value.Kind == yaml.SequenceNode:
+				lines = append(lines, fmt.Sprintf("- `%s` (%d)%s", key.Value, len(value.Content), from))
+			default:
+				lines = append(lines, fmt.Sprintf("- `%s`%s", key.Value, from))
+			}
+		}
+	}
+	return lsp.MarkupContent{Kind: "markdown", Value: strings.Join(lines, "\n")}, true
+}
@@ -0,0 +1,169 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"fmt"
+	"strings"
+)
+
+// Workers of a cluster, `low` and `high` are the same without autoscaling
+func clusterWorkers(cluster *yaml.Node) (low, high int64, found bool) {
+	if autoscale := cluster.Get("autoscale"); autoscale != nil {
+		low, lowFound := yaml.Resolve(autoscale.Get("min_workers")).(int64)
+		high, highFound := yaml.Resolve(autoscale.Get("max_workers")).(int64)
+		if !lowFound {
+			low = high
+		}
+		return low, high, lowFound || highFound
+	}
+	workers, found := yaml.Resolve(cluster.Get("num_workers")).(int64)
+	return workers, workers, found
+}
+
+// `2-8 workers, i3.xlarge`, the size of a cluster
+func describeCluster(cluster *yaml.Node) string {
+	parts := []string{}
+	if low, high, found := clusterWorkers(cluster); found {
+		switch {
+		case low != high:
+			parts = append(parts, fmt.Sprintf("%d-%d workers", low, high))
+		case high == 0:
+			parts = append(parts, "single node")
+		case high == 1:
+			parts = append(parts, "1 worker")
+		default:
+			parts = append(parts, fmt.Sprintf("%d workers", high))
+		}
+	}
+	if node := cluster.Get("node_type_id"); node != nil && node.Kind == yaml.ScalarNode && node.Value != "" {
+		parts = append(parts, node.Value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Job clusters by key, aliases and merge keys expanded
+func jobClusters(root *yaml.Node) map[string]*yaml.Node {
+	clusters := map[string]*yaml.Node{}
+	if expanded, err := yaml.Expand(root); err == nil {
+		root = expanded
+	}
+	if declared := root.Get("job_clusters"); declared != nil {
+		for _, cluster := range declared.Content {
+			if key := cluster.Get("job_cluster_key"); key != nil && key.Kind == yaml.ScalarNode {
+				clusters[key.Value] = cluster.Get("new_cluster")
+			}
+		}
+	}
+	return clusters
+}
+
+// Tasks downstream of each task and the length of the longest chain of tasks upstream of it
+// Tasks in a dependency cycle have no depth
+func taskDepths(graph workflow.Graph) (downstream map[string]int, depth map[string]int) {
+	upstream, dependents := map[string][]string{}, map[string][]string{}
+	for _, edge := range graph.Edges {
+		upstream[edge.To] = append(upstream[edge.To], edge.From)
+		dependents[edge.From] = append(dependents[edge.From], edge.To)
+	}
+
+	depth = map[string]int{}
+	visiting := map[string]bool{}
+	var measure func(key string) (int, bool)
+	measure = func(key string) (int, bool) {
+		if d, found := depth[key]; found {
+			return d, true
+		}
+		if visiting[key] {
+			return 0, false
+		}
+		visiting[key] = true
+		d := 0
+		for _, from := range upstream[key] {
+			above, ok := measure(from)
+			if !ok {
+				return 0, false
+			}
+			d = max(d, above+1)
+		}
+		visiting[key] = false
+		depth[key] = d
+		return d, true
+	}
+
+	downstream = map[string]int{}
+	for _, task := range graph.Tasks {
+		measure(task.Key)
+		seen := map[string]bool{}
+		queue := []string{task.Key}
+		for len(queue) > 0 {
+			for _, next := range dependents[queue[0]] {
+				if !seen[next] && next != task.Key {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+			queue = queue[1:]
+		}
+		downstream[task.Key] = len(seen)
+	}
+	return downstream, depth
+}
+
+// Hints of a document on the lines of a range:
+// dependents and depth of each task, durations for humans and the size of the job cluster of each task
+func inlayHints(root *yaml.Node, within lsp.Range) []lsp.InlayHint {
+	hints := []lsp.InlayHint{}
+	add := func(n *yaml.Node, label string) {
+		if label == "" || n.EndLine < within.Start.Line || n.EndLine > within.End.Line {
+			return
+		}
+		hints = append(hints, lsp.InlayHint{
+			Position:    lsp.Position{Line: n.EndLine, Character: n.EndColumn},
+			Label:       label,
+			PaddingLeft: true,
+		})
+	}
+
+	expanded, err := yaml.Expand(root)
+	if err != nil {
+		expanded = root
+	}
+	settings, _ := workflow.Settings(expanded)
+	downstream, depth := taskDepths(workflow.NewGraph(settings))
+	clusters := jobClusters(root)
+
+	var walk func(n *yaml.Node, path []string)
+	walk = func(n *yaml.Node, path []string) {
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				keyPath := append(append([]string{}, path...), key.Value)
+				switch {
+				case value.Kind != yaml.ScalarNode:
+					walk(value, keyPath)
+				case len(keyPath) == 2 && keyPath[0] == "tasks" && key.Value == "task_key":
+					if d, found := depth[value.Value]; found {
+						add(value, fmt.Sprintf("%d downstream, depth %d", downstream[value.Value], d))
+					}
+				case key.Value == "job_cluster_key" && keyPath[0] == "tasks":
+					if cluster, found := clusters[value.Value]; found && cluster != nil {
+						add(value, describeCluster(cluster))
+					}
+				default:
+					if duration := durationOf(key, value, n); duration > 0 {
+						add(value, workflow.HumanDuration(duration))
+					}
+				}
+			}
+		}
+	}
+	walk(root, nil)
+	return hints
+}
@@ -0,0 +1,63 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestInlayHintDepths(t *testing.T) {
+	tests := []struct {
+		document string
+		expected []string // line of the hint and its label
+	}{
+		// Diamond, `d` is counted once downstream of `a`
+		{`tasks:
+  - task_key: a
+  - task_key: b
+    depends_on:
+      - task_key: a
+  - task_key: c
+    depends_on:
+      - task_key: a
+  - task_key: d
+    depends_on:
+      - task_key: b
+      - task_key: c
+`, []string{
+			"1: 3 downstream, depth 0",
+			"2: 1 downstream, depth 1",
+			"5: 1 downstream, depth 1",
+			"8: 0 downstream, depth 2",
+		}},
+		// Cycle, tasks in it or after it have no depth
+		{`tasks:
+  - task_key: x
+    depends_on:
+      - task_key: y
+  - task_key: y
+    depends_on:
+      - task_key: x
+  - task_key: z
+    depends_on:
+      - task_key: x
+  - task_key: alone
+`, []string{
+			"10: 0 downstream, depth 0",
+		}},
+	}
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///x.flow.yaml"] = test.document
+		within := lsp.Range{End: lsp.Position{Line: 100}}
+		actual := []string{}
+		for _, hint := range state.InlayHint(1, "file:///x.flow.yaml", within).Result {
+			actual = append(actual, fmt.Sprintf("%d: %s", hint.Position.Line, hint.Label))
+		}
+		if !slices.Equal(test.expected, actual) {
+			t.Fatalf("Expected: %s, Actual: %s", test.expected, actual)
+		}
+	}
+}
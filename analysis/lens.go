@@ -0,0 +1,179 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Size of a node type in the name of AWS and GCP instances, e.g. `i3.2xlarge` or `n2-standard-8`
+var (
+	awsSize = regexp.MustCompile(`\.(\d*)xlarge$`)
+	gcpSize = regexp.MustCompile(`^[a-z]\d[a-z]?-(standard|highmem|highcpu)-(\d+)$`)
+)
+
+// Cores of the Azure node types, their names don't tell, e.g. `Standard_DS3_v2` has 4
+var azureCores = map[string]int{
+	"Standard_D3_v2": 4, "Standard_D4_v2": 8, "Standard_D5_v2": 16,
+	"Standard_D12_v2": 4, "Standard_D13_v2": 8, "Standard_D14_v2": 16, "Standard_D15_v2": 20,
+	"Standard_DS3_v2": 4, "Standard_DS4_v2": 8, "Standard_DS5_v2": 16,
+	"Standard_DS12_v2": 4, "Standard_DS13_v2": 8, "Standard_DS14_v2": 16, "Standard_DS15_v2": 20,
+	"Standard_D4s_v3": 4, "Standard_D8s_v3": 8, "Standard_D16s_v3": 16, "Standard_D32s_v3": 32, "Standard_D64s_v3": 64,
+	"Standard_D4ds_v4": 4, "Standard_D8ds_v4": 8, "Standard_D16ds_v4": 16, "Standard_D32ds_v4": 32, "Standard_D64ds_v4": 64,
+	"Standard_D4ds_v5": 4, "Standard_D8ds_v5": 8, "Standard_D16ds_v5": 16, "Standard_D32ds_v5": 32, "Standard_D64ds_v5": 64,
+	"Standard_D4as_v5": 4, "Standard_D8as_v5": 8, "Standard_D16as_v5": 16, "Standard_D32as_v5": 32, "Standard_D64as_v5": 64,
+	"Standard_E4s_v3": 4, "Standard_E8s_v3": 8, "Standard_E16s_v3": 16, "Standard_E32s_v3": 32, "Standard_E64s_v3": 64,
+	"Standard_E4ds_v4": 4, "Standard_E8ds_v4": 8, "Standard_E16ds_v4": 16, "Standard_E32ds_v4": 32, "Standard_E64ds_v4": 64,
+	"Standard_E4ds_v5": 4, "Standard_E8ds_v5": 8, "Standard_E16ds_v5": 16, "Standard_E32ds_v5": 32, "Standard_E64ds_v5": 64,
+	"Standard_F4s": 4, "Standard_F8s": 8, "Standard_F16s": 16,
+	"Standard_F4s_v2": 4, "Standard_F8s_v2": 8, "Standard_F16s_v2": 16, "Standard_F32s_v2": 32, "Standard_F64s_v2": 64, "Standard_F72s_v2": 72,
+	"Standard_L8s_v2": 8, "Standard_L16s_v2": 16, "Standard_L32s_v2": 32, "Standard_L64s_v2": 64,
+	"Standard_L8s_v3": 8, "Standard_L16s_v3": 16, "Standard_L32s_v3": 32, "Standard_L64s_v3": 64,
+	"Standard_NC6s_v3": 6, "Standard_NC12s_v3": 12, "Standard_NC24s_v3": 24,
+	"Standard_NC4as_T4_v3": 4, "Standard_NC8as_T4_v3": 8, "Standard_NC16as_T4_v3": 16, "Standard_NC64as_T4_v3": 64,
+}
+
+// Rough DBU per hour of a node, a quarter of its cores
+// The estimate is left out for node types whose cores aren't known
+func nodeDBU(nodeType string) (float64, bool) {
+	cores := azureCores[nodeType]
+	switch {
+	case cores > 0:
+	case strings.HasSuffix(nodeType, ".large"):
+		cores = 2
+	case awsSize.MatchString(nodeType):
+		cores = 4
+		if n, err := strconv.Atoi(awsSize.FindStringSubmatch(nodeType)[1]); err == nil {
+			cores = 4 * n
+		}
+	case gcpSize.MatchString(nodeType):
+		cores, _ = strconv.Atoi(gcpSize.FindStringSubmatch(nodeType)[2])
+	}
+	return float64(cores) / 4, cores > 0
+}
+
+// Rough DBU per hour of a cluster at its largest, the driver and every worker
+func clusterDBU(cluster *yaml.Node) (float64, bool) {
+	nodeType, driverType := "", ""
+	if node := cluster.Get("node_type_id"); node != nil {
+		nodeType = node.Value
+	}
+	driverType = nodeType
+	if driver := cluster.Get("driver_node_type_id"); driver != nil {
+		driverType = driver.Value
+	}
+	_, workers, _ := clusterWorkers(cluster)
+	driver, known := nodeDBU(driverType)
+	worker, knownWorker := nodeDBU(nodeType)
+	return driver + float64(workers)*worker, known && (knownWorker || workers == 0)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// Lenses of a document: the number of uses above each task and job cluster, the upstream tasks of each task,
+// and at the top the size of the workflow with the DBU it may use when all its clusters run at once
+func codeLenses(root *yaml.Node, uri string) []lsp.CodeLens {
+	lenses := []lsp.CodeLens{}
+	index := indexReferences(root)
+
+	tasks, clusters, dbu, estimated := 0, 0, 0.0, true
+	addCluster := func(cluster *yaml.Node) {
+		amount, known := clusterDBU(cluster)
+		dbu += amount
+		estimated = estimated && known
+	}
+	for _, cluster := range jobClusters(root) {
+		clusters++
+		if cluster != nil {
+			addCluster(cluster)
+		}
+	}
+	for _, r := range index {
+		if !r.definition {
+			continue
+		}
+		if r.kind == "task" {
+			tasks++
+		}
+		uses := []lsp.Location{}
+		for _, use := range index.of(r.symbol) {
+			if !use.definition {
+				uses = append(uses, keyLocation(uri, use.node))
+			}
+		}
+		lenses = append(lenses, lsp.CodeLens{
+			Range:   nodeRange(r.node),
+			Command: showReferences(plural(len(uses), "reference"), uri, nodeRange(r.node).Start, uses),
+		})
+	}
+
+	var task func(n *yaml.Node)
+	task = func(n *yaml.Node) {
+		if cluster := n.Get("new_cluster"); cluster != nil {
+			addCluster(cluster)
+		}
+		key, dependencies := n.Get("task_key"), n.Get("depends_on")
+		if key != nil && dependencies != nil && len(dependencies.Content) > 0 {
+			upstream, locations := []string{}, []lsp.Location{}
+			for _, dependency := range dependencies.Content {
+				name := dependency.Get("task_key")
+				if name == nil {
+					continue
+				}
+				upstream = append(upstream, name.Value)
+				if definition, found := index.definition(symbol{"task", name.Value}); found {
+					locations = append(locations, keyLocation(uri, definition.node))
+				}
+			}
+			lenses = append(lenses, lsp.CodeLens{
+				Range:   nodeRange(key),
+				Command: showReferences("upstream: "+strings.Join(upstream, ", "), uri, nodeRange(key).Start, locations),
+			})
+		}
+		if nested := n.Get("for_each_task").Get("task"); nested != nil {
+			task(nested)
+		}
+	}
+	if declared := root.Get("tasks"); declared != nil {
+		for _, n := range declared.Content {
+			task(n)
+		}
+	}
+
+	summary := plural(tasks, "task") + ", " + plural(clusters, "job cluster")
+	if dbu > 0 && estimated {
+		summary += fmt.Sprintf(", ~%s DBU/h at most", strconv.FormatFloat(dbu, 'f', -1, 64))
+	}
+	// The summary lists the declared tasks and job clusters
+	declarations := []lsp.Location{}
+	for _, r := range index {
+		if r.definition {
+			declarations = append(declarations, keyLocation(uri, r.node))
+		}
+	}
+	top := lsp.CodeLens{Range: lsp.LineRange(0, 0, 0), Command: showReferences(summary, uri, lsp.Position{}, declarations)}
+	return append([]lsp.CodeLens{top}, lenses...)
+}
+
+// Command of a lens listing locations, the one VS Code shows references with and other clients can register
+// Arguments are the uri of the document, the position of the lens and the locations
+func showReferences(title, uri string, position lsp.Position, locations []lsp.Location) *lsp.Command {
+	return &lsp.Command{
+		Title:     title,
+		Command:   "editor.action.showReferences",
+		Arguments: []any{uri, position, locations},
+	}
+}
+
+// Location of a task or job cluster key
+func keyLocation(uri string, key *yaml.Node) lsp.Location {
+	return lsp.Location{URI: uri, Range: scalarRange(key, 0, len(key.Value))}
+}
@@ -0,0 +1,66 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCodeLens(t *testing.T) {
+	document := `name: lenses
+job_clusters:
+  - job_cluster_key: main
+    new_cluster:
+      node_type_id: Standard_DS3_v2
+      num_workers: 2
+tasks:
+  - task_key: ingest
+    job_cluster_key: main
+  - task_key: ingest
+    job_cluster_key: main
+  - task_key: report
+    depends_on:
+      - task_key: ingest
+`
+	tests := []struct {
+		document string
+		expected []string // title and locations of each lens, in order
+	}{
+		{document, []string{
+			"3 tasks, 1 job cluster, ~3 DBU/h at most 7:14 9:14 11:14 2:21",
+			"1 reference 13:18",
+			"1 reference 13:18",
+			"0 references",
+			"2 references 8:21 10:21",
+			"upstream: ingest 7:14",
+		}},
+		{strings.Replace(document, "Standard_DS3_v2", "Standard_Unknown_v9", 1), []string{
+			"3 tasks, 1 job cluster 7:14 9:14 11:14 2:21",
+			"1 reference 13:18",
+			"1 reference 13:18",
+			"0 references",
+			"2 references 8:21 10:21",
+			"upstream: ingest 7:14",
+		}},
+	}
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///lenses.flow.yaml"] = test.document
+		actual := []string{}
+		for _, lens := range state.CodeLens(1, "file:///lenses.flow.yaml").Result {
+			if lens.Command == nil || lens.Command.Command != "editor.action.showReferences" || len(lens.Command.Arguments) != 3 {
+				t.Fatalf("Expected: editor.action.showReferences, Actual: %+v", lens.Command)
+			}
+			described := lens.Command.Title
+			for _, location := range lens.Command.Arguments[2].([]lsp.Location) {
+				described += fmt.Sprintf(" %d:%d", location.Range.Start.Line, location.Range.Start.Character)
+			}
+			actual = append(actual, described)
+		}
+		if strings.Join(test.expected, "\n") != strings.Join(actual, "\n") {
+			t.Fatalf("Expected: %s, Actual: %s", test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+)
+
+// A task or a job cluster, by the key naming it
+type symbol struct {
+	kind string // `task` or `job_cluster`
+	name string
+}
+
+// Where a task or job cluster key is written, where it is declared or where it is used
+type reference struct {
+	symbol
+	node       *yaml.Node
+	definition bool
+}
+
+// Task and job cluster keys of a document, in the order they are written
+type referenceIndex []reference
+
+// Every declaration and use of a task or job cluster key:
+// `task_key` of the tasks and `job_cluster_key` of `job_clusters` declare them,
+// `task_key` in `depends_on` and `job_cluster_key` in the tasks use them
+func indexReferences(root *yaml.Node) referenceIndex {
+	index := referenceIndex{}
+	scalar := func(n *yaml.Node) bool {
+		return n != nil && n.Kind == yaml.ScalarNode
+	}
+
+	var task func(n *yaml.Node)
+	task = func(n *yaml.Node) {
+		if key := n.Get("task_key"); scalar(key) {
+			index = append(index, reference{symbol{"task", key.Value}, key, true})
+		}
+		if dependencies := n.Get("depends_on"); dependencies != nil {
+			for _, dependency := range dependencies.Content {
+				if key := dependency.Get("task_key"); scalar(key) {
+					index = append(index, reference{symbol{"task", key.Value}, key, false})
+				}
+			}
+		}
+		if cluster := n.Get("job_cluster_key"); scalar(cluster) {
+			index = append(index, reference{symbol{"job_cluster", cluster.Value}, cluster, false})
+		}
+		if nested := n.Get("for_each_task").Get("task"); nested != nil {
+			task(nested)
+		}
+	}
+	if tasks := root.Get("tasks"); tasks != nil && tasks.Kind == yaml.SequenceNode {
+		for _, n := range tasks.Content {
+			task(n)
+		}
+	}
+	if clusters := root.Get("job_clusters"); clusters != nil && clusters.Kind == yaml.SequenceNode {
+		for _, cluster := range clusters.Content {
+			if key := cluster.Get("job_cluster_key"); scalar(key) {
+				index = append(index, reference{symbol{"job_cluster", key.Value}, key, true})
+			}
+		}
+	}
+	return index
+}
+
+// Reference at a position
+func (index referenceIndex) at(position lsp.Position) (reference, bool) {
+	for _, r := range index {
+		if contains(r.node, position) {
+			return r, true
+		}
+	}
+	return reference{}, false
+}
+
+// Declaration of a task or job cluster, the first one when it is declared more than once
+func (index referenceIndex) definition(s symbol) (reference, bool) {
+	for _, r := range index {
+		if r.symbol == s && r.definition {
+			return r, true
+		}
+	}
+	return reference{}, false
+}
+
+// Declarations and uses of a task or job cluster
+func (index referenceIndex) of(s symbol) []reference {
+	references := []reference{}
+	for _, r := range index {
+		if r.symbol == s {
+			references = append(references, r)
+		}
+	}
+	return references
+}
+
+// Locations of the uses of the task or job cluster at a position, and of its declarations when asked
+func findReferences(document, uri string, position lsp.Position, declarations bool) []lsp.Location {
+	locations := []lsp.Location{}
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return locations
+	}
+	index := indexReferences(root)
+	at, found := index.at(position)
+	if !found {
+		return locations
+	}
+	for _, r := range index.of(at.symbol) {
+		if declarations || !r.definition {
+			locations = append(locations, lsp.Location{URI: uri, Range: scalarRange(r.node, 0, len(r.node.Value))})
+		}
+	}
+	return locations
+}
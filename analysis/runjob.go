@@ -0,0 +1,198 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Workflow parsed once for as long as its content stays the same, nil when it doesn't parse
+type parsedWorkflow struct {
+	content string
+	root    *yaml.Node
+}
+
+// Tree of a workflow of the workspace, parsed again only when it changed
+// Every `run_job_task` of a document looks the workspace up, parsing it each time would be slow
+func (s *State) parseWorkflow(uri, content string) *yaml.Node {
+	if cached, found := s.parsed[uri]; found && cached.content == content {
+		return cached.root
+	}
+	root, err := yaml.Parse(content)
+	if err != nil {
+		root = nil
+	}
+	s.parsed[uri] = parsedWorkflow{content, root}
+	return root
+}
+
+// Workflow of the workspace a `job_name` refers to, by the name of its job or by the path of its file
+// from the folder of the document. Empty uri when there is none
+func (s *State) findWorkflow(uri, name string) (string, *yaml.Node) {
+	workflows := s.workflows()
+	uris := []string{}
+	for candidate := range workflows {
+		uris = append(uris, candidate)
+	}
+	slices.Sort(uris)
+	for cached := range s.parsed {
+		if _, found := workflows[cached]; !found {
+			delete(s.parsed, cached)
+		}
+	}
+
+	path := ""
+	if strings.HasSuffix(name, workflowSuffix) {
+		path = filepath.Join(filepath.Dir(uriPath(uri)), name)
+	}
+	for _, candidate := range uris {
+		if path != "" && uriPath(candidate) != path {
+			continue
+		}
+		root := s.parseWorkflow(candidate, workflows[candidate])
+		if root == nil {
+			continue
+		}
+		if path != "" || workflow.JobName(root, uriPath(candidate)) == name {
+			return candidate, root
+		}
+	}
+	return "", nil
+}
+
+// `job_name` of a `run_job_task` at a position, nil when the position is elsewhere
+func runJobNameAt(root *yaml.Node, position lsp.Position) *yaml.Node {
+	for _, run := range workflow.RunJobTasks(root) {
+		if name := run.Get("job_name"); name != nil && name.Kind == yaml.ScalarNode && contains(name, position) {
+			return name
+		}
+	}
+	return nil
+}
+
+// Parameters a job declares, by name with their default value
+func jobParameters(root *yaml.Node) [][2]string {
+	parameters := [][2]string{}
+	if declared := root.Get("parameters"); declared != nil && declared.Kind == yaml.SequenceNode {
+		for _, parameter := range declared.Content {
+			if name := parameter.Get("name"); name != nil && name.Kind == yaml.ScalarNode {
+				value := ""
+				if fallback := parameter.Get("default"); fallback != nil {
+					value = fallback.Value
+				}
+				parameters = append(parameters, [2]string{name.Value, value})
+			}
+		}
+	}
+	return parameters
+}
+
+// Hover of a `job_name`: the job it triggers, where it is and its parameters
+func (s *State) hoverRunJob(uri string, position lsp.Position) (lsp.MarkupContent, bool) {
+	root, err := yaml.Parse(s.Documents[uri])
+	if err != nil {
+		return lsp.MarkupContent{}, false
+	}
+	name := runJobNameAt(root, position)
+	if name == nil {
+		return lsp.MarkupContent{}, false
+	}
+	target, job := s.findWorkflow(uri, name.Value)
+	if job == nil {
+		return lsp.MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("`%s`\n\nNo workflow of the workspace is named so", name.Value),
+		}, true
+	}
+
+	file, err := filepath.Rel(filepath.Dir(uriPath(uri)), uriPath(target))
+	if err != nil {
+		file = uriPath(target)
+	}
+	lines := []string{fmt.Sprintf("**%s** in `%s`", workflow.JobName(job, uriPath(target)), file)}
+	if description := job.Get("description"); description != nil && description.Value != "" {
+		lines = append(lines, "", description.Value)
+	}
+	parameters := jobParameters(job)
+	if len(parameters) == 0 {
+		lines = append(lines, "", "No parameters")
+	} else {
+		lines = append(lines, "", "| Parameter | Default |", "| --- | --- |")
+		for _, parameter := range parameters {
+			lines = append(lines, fmt.Sprintf("| `%s` | `%s` |", parameter[0], parameter[1]))
+		}
+	}
+	return lsp.MarkupContent{Kind: "markdown", Value: strings.Join(lines, "\n")}, true
+}
+
+// Location of the job a `job_name` at a position refers to, on its name or at the top of its file
+func (s *State) runJobDefinition(uri string, position lsp.Position) (lsp.Location, bool) {
+	root, err := yaml.Parse(s.Documents[uri])
+	if err != nil {
+		return lsp.Location{}, false
+	}
+	name := runJobNameAt(root, position)
+	if name == nil {
+		return lsp.Location{}, false
+	}
+	target, job := s.findWorkflow(uri, name.Value)
+	if job == nil {
+		return lsp.Location{}, false
+	}
+	location := lsp.Location{URI: target, Range: lsp.LineRange(0, 0, 0)}
+	if declared := job.Get("name"); declared != nil {
+		location.Range = nodeRange(declared)
+	}
+	return location, true
+}
+
+// `job_name` of the workflows that aren't in the workspace, and `job_parameters` the job doesn't declare
+func (s *State) diagnoseRunJobs(root *yaml.Node, uri string) []lsp.Diagnostics {
+	diagnostics := []lsp.Diagnostics{}
+	for _, run := range workflow.RunJobTasks(root) {
+		name := run.Get("job_name")
+		if name == nil || name.Kind != yaml.ScalarNode {
+			continue
+		}
+		_, job := s.findWorkflow(uri, name.Value)
+		if job == nil {
+			diagnostics = append(diagnostics, lsp.Diagnostics{
+				Range:    nodeRange(name),
+				Severity: 1,
+				Source:   "dbwf-ls",
+				Message:  fmt.Sprintf("Workflow `%s` is not in the workspace.", name.Value),
+			})
+			continue
+		}
+
+		passed := run.Get("job_parameters")
+		if passed == nil || passed.Kind != yaml.MappingNode {
+			continue
+		}
+		declared := []string{}
+		for _, parameter := range jobParameters(job) {
+			declared = append(declared, "`"+parameter[0]+"`")
+		}
+		for i := 0; i+1 < len(passed.Content); i += 2 {
+			key := passed.Content[i]
+			if slices.Contains(declared, "`"+key.Value+"`") {
+				continue
+			}
+			message := fmt.Sprintf("`%s` is not a parameter of `%s`, it declares none.", key.Value, name.Value)
+			if len(declared) > 0 {
+				message = fmt.Sprintf("`%s` is not a parameter of `%s`, expected one of %s.", key.Value, name.Value, strings.Join(declared, ", "))
+			}
+			diagnostics = append(diagnostics, lsp.Diagnostics{
+				Range:    nodeRange(key),
+				Severity: 2,
+				Source:   "dbwf-ls",
+				Message:  message,
+			})
+		}
+	}
+	return diagnostics
+}
@@ -0,0 +1,59 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunJobDiagnostics(t *testing.T) {
+	folder := t.TempDir()
+	os.WriteFile(filepath.Join(folder, "nightly.flow.yaml"), []byte("name: nightly\nparameters:\n  - name: date\n    default: today\n"), 0o644)
+	uri := func(file string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.Join(folder, file)}).String()
+	}
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	state.IndexWorkspace([]string{uri("")}, logger)
+
+	trigger := `name: trigger
+tasks:
+  - task_key: first
+    run_job_task:
+      job_name: nightly
+      job_parameters:
+        date: yesterday
+        region: eu
+  - task_key: second
+    run_job_task:
+      job_name: nightly.flow.yaml
+`
+	// Only the diagnostics of the `run_job_task`
+	diagnose := func() []string {
+		messages := []string{}
+		for _, diagnostic := range state.UpdateDocument(uri("trigger.flow.yaml"), trigger, logger).Params.Diagnostics {
+			if strings.Contains(diagnostic.Message, "nightly") {
+				messages = append(messages, diagnostic.Message)
+			}
+		}
+		return messages
+	}
+
+	expected := []string{"`region` is not a parameter of `nightly`, expected one of `date`."}
+	if actual := diagnose(); !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+
+	// The job is looked up again once its workflow changed
+	state.OpenDocument(uri("nightly.flow.yaml"), "name: weekly\n", logger)
+	expected = []string{"Workflow `nightly` is not in the workspace."}
+	if actual := diagnose(); !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"dbwf-ls/cron"
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Value of `timezone_id` typed so far, without its opening quote
+var timezonePrefix = regexp.MustCompile(`^\s*timezone_id:\s*["']?([\w/+\-]*)$`)
+
+// How many fire times the hover of a cron expression lists
+const fireTimes = 5
+
+// Quartz syntax of `schedule.quartz_cron_expression`, errors point at the faulty field
+// and `schedule.timezone_id` must be a known time zone
+func diagnoseSchedule(root *yaml.Node) []lsp.Diagnostics {
+	diagnostics := []lsp.Diagnostics{}
+	if timezone := root.Get("schedule").Get("timezone_id"); timezone != nil && timezone.Kind == yaml.ScalarNode && !timezone.IsNull() {
+		if !slices.Contains(workflow.TimeZones, timezone.Value) {
+			message := fmt.Sprintf("Unknown `timezone_id` `%s`", timezone.Value)
+			if suggestion := workflow.SuggestTimeZone(timezone.Value); suggestion != "" {
+				message += fmt.Sprintf(", did you mean `%s`?", suggestion)
+			}
+			diagnostics = append(diagnostics, lsp.Diagnostics{
+				Range:    nodeRange(timezone),
+				Severity: 1,
+				Source:   "dbwf-ls",
+				Message:  message,
+			})
+		}
+	}
+
+	expression := root.Get("schedule").Get("quartz_cron_expression")
+	if expression == nil || expression.Kind != yaml.ScalarNode || expression.IsNull() {
+		return diagnostics
+	}
+
+	schedule, err := cron.Parse(expression.Value)
+	var cronError *cron.Error
+	if errors.As(err, &cronError) {
+		return append(diagnostics, lsp.Diagnostics{
+			Range:    scalarRange(expression, cronError.Start, max(cronError.End, cronError.Start+1)),
+			Severity: 1,
+			Source:   "dbwf-ls",
+			Message:  fmt.Sprintf("Invalid Quartz cron expression, %s", cronError.Message),
+		})
+	}
+	// A schedule of past years still fires in them, diagnostics don't change with the clock
+	if !schedule.Fires() {
+		return append(diagnostics, lsp.Diagnostics{
+			Range:    nodeRange(expression),
+			Severity: 2,
+			Source:   "dbwf-ls",
+			Message:  "This schedule never fires",
+		})
+	}
+	return diagnostics
+}
+
+// Quick fix of a misspelled `timezone_id`, nil when it is known or nothing is close
+func fixTimeZone(root *yaml.Node, uri string) *lsp.CodeAction {
+	timezone := root.Get("schedule").Get("timezone_id")
+	if timezone == nil || timezone.Kind != yaml.ScalarNode || timezone.IsNull() || slices.Contains(workflow.TimeZones, timezone.Value) {
+		return nil
+	}
+	suggestion := workflow.SuggestTimeZone(timezone.Value)
+	if suggestion == "" {
+		return nil
+	}
+	return &lsp.CodeAction{
+		Title:       fmt.Sprintf("Replace with `%s`", suggestion),
+		Kind:        "quickfix",
+		IsPreferred: true,
+		Edit: &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{
+			uri: {{Range: scalarRange(timezone, 0, len(timezone.Value)), NewText: suggestion}},
+		}},
+	}
+}
+
+// Time zones to complete after `timezone_id:`, replacing what is already typed of the value
+func completeTimeZone(line string, position lsp.Position) []lsp.CompletionItem {
+	matches := timezonePrefix.FindStringSubmatchIndex(line[:min(position.Character, len(line))])
+	if matches == nil {
+		return nil
+	}
+	typed := lsp.LineRange(position.Line, matches[2], position.Character)
+	prefix := strings.ToLower(line[matches[2]:matches[3]])
+	items := []lsp.CompletionItem{}
+	for _, zone := range workflow.TimeZones {
+		if !strings.Contains(strings.ToLower(zone), prefix) {
+			continue
+		}
+		items = append(items, lsp.CompletionItem{
+			Label:      zone,
+			Kind:       lsp.CompletionItemKind["Value"],
+			Detail:     "time zone",
+			InsertText: zone,
+			TextEdit:   &lsp.TextEdit{Range: typed, NewText: zone},
+		})
+	}
+	return items
+}
+
+// Hover of a cron expression, in English with its next fire times in the `timezone_id` of the schedule
+func hoverSchedule(expression *yaml.Node, schedule *yaml.Node) lsp.MarkupContent {
+	parsed, err := cron.Parse(expression.Value)
+	if err != nil {
+		return lsp.MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("`%s`\n\nInvalid Quartz cron expression: %s", expression.Value, err),
+		}
+	}
+
+	lines := []string{fmt.Sprintf("`%s`", expression.Value), "", parsed.Describe()}
+	zone := "UTC"
+	if timezone := schedule.Get("timezone_id"); timezone != nil && timezone.Kind == yaml.ScalarNode && !timezone.IsNull() {
+		zone = timezone.Value
+	}
+	location, err := workflow.LoadTimeZone(zone)
+	if err != nil {
+		return lsp.MarkupContent{
+			Kind:  "markdown",
+			Value: strings.Join(append(lines, "", fmt.Sprintf("Unknown `timezone_id` `%s`, no fire times", zone)), "\n"),
+		}
+	}
+
+	lines = append(lines, "", fmt.Sprintf("Next fire times (%s):", zone))
+	next := time.Now().In(location)
+	for i := 0; i < fireTimes; i++ {
+		next = parsed.Next(next)
+		if next.IsZero() {
+			break
+		}
+		lines = append(lines, "- "+next.Format("Mon 2006-01-02 15:04:05 MST"))
+	}
+	return lsp.MarkupContent{
+		Kind:  "markdown",
+		Value: strings.Join(lines, "\n"),
+	}
+}
@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/yaml"
+	"strings"
+)
+
+// Whether a position is inside a range, both ends included
+func inRange(r lsp.Range, position lsp.Position) bool {
+	after := position.Line > r.Start.Line || (position.Line == r.Start.Line && position.Character >= r.Start.Character)
+	before := position.Line < r.End.Line || (position.Line == r.End.Line && position.Character <= r.End.Character)
+	return after && before
+}
+
+// Ranges around a position, from the innermost: value, key and value, mapping, sequence item with its `-`,
+// the key holding the sequence... up to the whole document
+func selectionRange(document string, root *yaml.Node, position lsp.Position) lsp.SelectionRange {
+	lines := strings.Split(document, "\n")
+	ranges := []lsp.Range{{
+		Start: lsp.Position{Line: 0, Character: 0},
+		End:   lsp.Position{Line: len(lines) - 1, Character: len(lines[len(lines)-1])},
+	}}
+	add := func(r lsp.Range) {
+		if r != ranges[len(ranges)-1] {
+			ranges = append(ranges, r)
+		}
+	}
+
+	// The `-` of an item is outside of the item's node, the cursor may not be in it
+	for n := root; n != nil && inRange(nodeRange(n), position); {
+		add(nodeRange(n))
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				pair := lsp.Range{Start: nodeRange(key).Start, End: nodeRange(value).End}
+				if value.EndLine < key.EndLine || (value.EndLine == key.EndLine && value.EndColumn < key.EndColumn) {
+					pair.End = nodeRange(key).End
+				}
+				if !inRange(pair, position) {
+					continue
+				}
+				add(pair)
+				if inRange(nodeRange(key), position) {
+					add(nodeRange(key))
+				} else {
+					next = value
+				}
+				break
+			}
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				// The `-` of a block sequence is part of the item
+				itemRange := nodeRange(item)
+				if n.Style != yaml.FlowStyle && item.Line < len(lines) {
+					if dash := strings.LastIndex(lines[item.Line][:min(item.Column, len(lines[item.Line]))], "-"); dash >= 0 {
+						itemRange.Start.Character = dash
+					}
+				}
+				if inRange(itemRange, position) {
+					add(itemRange)
+					next = item
+					break
+				}
+			}
+		}
+		n = next
+	}
+
+	selection := lsp.SelectionRange{Range: ranges[0]}
+	for _, r := range ranges[1:] {
+		parent := selection
+		selection = lsp.SelectionRange{Range: r, Parent: &parent}
+	}
+	return selection
+}
@@ -0,0 +1,50 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSelectionRange(t *testing.T) {
+	document := "name: job\ntasks:\n  - task_key: ingest\n    notebook_task:\n      notebook_path: ./ingest\n"
+	tests := []struct {
+		position lsp.Position
+		expected []string // ranges from the innermost to the whole document
+	}{
+		// Scalar
+		{lsp.Position{Line: 4, Character: 24}, []string{
+			"4:21-4:29", "4:6-4:29", "3:4-4:29", "2:4-4:29", "2:2-4:29", "1:0-4:29", "0:0-4:29", "0:0-5:0",
+		}},
+		// Key
+		{lsp.Position{Line: 2, Character: 6}, []string{
+			"2:4-2:12", "2:4-2:20", "2:4-4:29", "2:2-4:29", "1:0-4:29", "0:0-4:29", "0:0-5:0",
+		}},
+		// Sequence item, on its `-`
+		{lsp.Position{Line: 2, Character: 2}, []string{
+			"2:2-4:29", "1:0-4:29", "0:0-4:29", "0:0-5:0",
+		}},
+		// Document root, past the mapping
+		{lsp.Position{Line: 5, Character: 0}, []string{
+			"0:0-5:0",
+		}},
+	}
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///x.flow.yaml"] = document
+		result := state.SelectionRange(1, "file:///x.flow.yaml", []lsp.Position{test.position}).Result
+		if len(result) != 1 {
+			t.Fatalf("Expected: 1 selection range, Actual: %d", len(result))
+		}
+		actual := []string{}
+		for selection := &result[0]; selection != nil; selection = selection.Parent {
+			r := selection.Range
+			actual = append(actual, fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character))
+		}
+		if strings.Join(test.expected, " ") != strings.Join(actual, " ") {
+			t.Fatalf("%v, Expected: %s, Actual: %s", test.position, test.expected, actual)
+		}
+	}
+}
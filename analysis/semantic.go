@@ -0,0 +1,153 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"regexp"
+	"slices"
+)
+
+// Types of semantic tokens, in the order of the legend
+const (
+	propertyToken        = iota // key of the schema
+	unknownPropertyToken        // key outside of the schema
+	taskToken                   // task key
+	clusterToken                // job cluster key
+	enumToken                   // value of an enum
+	referenceToken              // dynamic value reference, e.g. `{{job.id}}`
+)
+
+// Modifiers of semantic tokens, as bits in the order of the legend
+const (
+	declarationModifier = 1 << iota
+	deprecatedModifier
+)
+
+// Legend of the semantic tokens sent to the client
+func SemanticTokensLegend() lsp.SemanticTokensLegend {
+	return lsp.SemanticTokensLegend{
+		TokenTypes:     []string{"property", "unknownProperty", "class", "struct", "enumMember", "macro"},
+		TokenModifiers: []string{"declaration", "deprecated"},
+	}
+}
+
+var dynamicReference = regexp.MustCompile(`{{[^{}]*}}`)
+
+type semanticToken struct {
+	line, character, length int
+	kind, modifiers         int
+}
+
+// Semantic tokens of a document, in order
+// Only tokens on a single line are sent, the others are left to the syntax highlighting of the client
+func semanticTokens(root *yaml.Node) []semanticToken {
+	tokens := []semanticToken{}
+	add := func(r lsp.Range, kind, modifiers int) {
+		if r.Start.Line == r.End.Line && r.End.Character > r.Start.Character {
+			tokens = append(tokens, semanticToken{r.Start.Line, r.Start.Character, r.End.Character - r.Start.Character, kind, modifiers})
+		}
+	}
+
+	var walk func(n *yaml.Node, field *workflow.Field, path []string)
+	scalar := func(n *yaml.Node, field *workflow.Field, path []string) {
+		if n.Kind != yaml.ScalarNode || len(path) == 0 {
+			return
+		}
+		switch key := path[len(path)-1]; {
+		case key == "task_key":
+			modifiers := declarationModifier
+			if len(path) > 1 && path[len(path)-2] == "depends_on" {
+				modifiers = 0
+			}
+			add(nodeRange(n), taskToken, modifiers)
+			return
+		case key == "job_cluster_key":
+			modifiers := 0
+			if path[0] == "job_clusters" {
+				modifiers = declarationModifier
+			}
+			add(nodeRange(n), clusterToken, modifiers)
+			return
+		case field != nil && slices.Contains(field.Enum, n.Value):
+			add(nodeRange(n), enumToken, 0)
+			return
+		}
+		// References inside a longer string need a precise range
+		for _, match := range dynamicReference.FindAllStringIndex(n.Value, -1) {
+			if r := scalarRange(n, match[0], match[1]); r != nodeRange(n) || match[1]-match[0] == len(n.Value) {
+				add(r, referenceToken, 0)
+			}
+		}
+	}
+	walk = func(n *yaml.Node, field *workflow.Field, path []string) {
+		for field != nil && field.Type == workflow.ArrayType {
+			field = field.Items
+		}
+		switch n.Kind {
+		case yaml.ScalarNode:
+			scalar(n, field, path)
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item, field, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				var child *workflow.Field
+				switch {
+				case field == nil || field.Type == workflow.AnyType:
+				case field.Type == workflow.MapType:
+					child = field.Values
+					add(nodeRange(key), propertyToken, 0)
+				case field.Type == workflow.ObjectType:
+					child = field.Field(key.Value)
+					switch {
+					case child == nil:
+						add(nodeRange(key), unknownPropertyToken, 0)
+					case child.Deprecated != "":
+						add(nodeRange(key), propertyToken, deprecatedModifier)
+					default:
+						add(nodeRange(key), propertyToken, 0)
+					}
+				}
+				walk(value, child, append(slices.Clone(path), key.Value))
+			}
+		}
+	}
+	walk(root, workflow.Job, nil)
+
+	slices.SortFunc(tokens, func(a, b semanticToken) int {
+		if a.line != b.line {
+			return a.line - b.line
+		}
+		return a.character - b.character
+	})
+	return tokens
+}
+
+// Tokens in the relative encoding of the protocol, only those on the lines of a range when it is given
+func encodeSemanticTokens(tokens []semanticToken, within *lsp.Range) lsp.SemanticTokens {
+	data := []int{}
+	line, character := 0, 0
+	for _, token := range tokens {
+		if within != nil && (token.line < within.Start.Line || token.line > within.End.Line) {
+			continue
+		}
+		if token.line != line {
+			character = 0
+		}
+		data = append(data, token.line-line, token.character-character, token.length, token.kind, token.modifiers)
+		line, character = token.line, token.character
+	}
+	return lsp.SemanticTokens{Data: data}
+}
+
+// Semantic tokens of a document, empty when it doesn't parse
+func documentSemanticTokens(document string, within *lsp.Range) lsp.SemanticTokens {
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return lsp.SemanticTokens{Data: []int{}}
+	}
+	return encodeSemanticTokens(semanticTokens(root), within)
+}
@@ -0,0 +1,52 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"slices"
+	"testing"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	document := `name: job
+format: MULTI_TASK
+tasks:
+  - task_key: ingest
+    run_if: ALL_DONE
+    depends_on:
+      - task_key: setup
+`
+	tests := []struct {
+		within   *lsp.Range
+		expected []int // line and character relative to the previous token, length, type and modifiers
+	}{
+		{nil, []int{
+			0, 0, 4, 0, 0,
+			1, 0, 6, 0, 2, // deprecated property
+			0, 8, 10, 4, 0,
+			1, 0, 5, 0, 0,
+			1, 4, 8, 0, 0,
+			0, 10, 6, 2, 1, // declared task
+			1, 4, 6, 0, 0,
+			0, 8, 8, 4, 0,
+			1, 4, 10, 0, 0,
+			1, 8, 8, 0, 0,
+			0, 10, 5, 2, 0,
+		}},
+		// The first token of a range is relative to the start of the document
+		{&lsp.Range{Start: lsp.Position{Line: 3, Character: 10}, End: lsp.Position{Line: 4, Character: 0}}, []int{
+			3, 4, 8, 0, 0,
+			0, 10, 6, 2, 1,
+			1, 4, 6, 0, 0,
+			0, 8, 8, 4, 0,
+		}},
+	}
+	for _, test := range tests {
+		state := analysis.NewState()
+		state.Documents["file:///x.flow.yaml"] = document
+		actual := state.SemanticTokens(1, "file:///x.flow.yaml", test.within).Result.Data
+		if !slices.Equal(test.expected, actual) {
+			t.Fatalf("Expected: %v, Actual: %v", test.expected, actual)
+		}
+	}
+}
@@ -2,18 +2,31 @@ package analysis
 
 import (
 	"dbwf-ls/lsp"
-	"errors"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"fmt"
 	"log"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 type State struct {
 	Documents map[string]string
+	Workspace map[string]string // `.flow.yaml` files of the workspace as they are on disk, by uri
+	Options   lsp.InitializationOptions
+
+	workspaceLock *sync.Mutex               // the workspace is indexed in the background, while requests are handled
+	parsed        map[string]parsedWorkflow // workflows parsed to look up a `job_name`, by uri
 }
 
 func NewState() State {
-	return State{Documents: map[string]string{}}
+	return State{
+		Documents:     map[string]string{},
+		Workspace:     map[string]string{},
+		workspaceLock: &sync.Mutex{},
+		parsed:        map[string]parsedWorkflow{},
+	}
 }
 
 // Handler for when document opened
@@ -23,7 +36,7 @@ func NewState() State {
 func (s *State) OpenDocument(uri, text string, logger *log.Logger) lsp.PublishDiagnosticsNotification {
 	s.Documents[uri] = text
 
-	diagnostics := diagnose(s.Documents[uri], logger)
+	diagnostics := s.diagnose(uri, logger)
 
 	return lsp.PublishDiagnosticsNotification{
 		Notification: lsp.Notification{
@@ -44,7 +57,7 @@ func (s *State) OpenDocument(uri, text string, logger *log.Logger) lsp.PublishDi
 func (s *State) UpdateDocument(uri, text string, logger *log.Logger) lsp.PublishDiagnosticsNotification {
 	s.Documents[uri] = text
 
-	diagnostics := diagnose(s.Documents[uri], logger)
+	diagnostics := s.diagnose(uri, logger)
 
 	return lsp.PublishDiagnosticsNotification{
 		Notification: lsp.Notification{
@@ -58,11 +71,54 @@ func (s *State) UpdateDocument(uri, text string, logger *log.Logger) lsp.Publish
 	}
 }
 
+// Diagnostics of a document, with its included files, the other workflows of the workspace
+// and the checks turned on by the options of the client
+func (s *State) diagnose(uri string, logger *log.Logger) []lsp.Diagnostics {
+	return s.diagnoseDocument(uri, s.Documents[uri], s.readFile, logger)
+}
+
+// Diagnostics of a document parsed once, `read` reads the files it includes
+func (s *State) diagnoseDocument(uri, document string, read func(path string) (string, error), logger *log.Logger) []lsp.Diagnostics {
+	root, err := yaml.Parse(document)
+	if err != nil {
+		root = nil
+	}
+	included, diagnostics := includes(root, uri, read)
+	diagnostics = append(diagnose(document, root, included, logger), diagnostics...)
+	if root != nil {
+		diagnostics = append(diagnostics, s.diagnoseRunJobs(root, uri)...)
+		if s.Options.CheckFiles {
+			diagnostics = append(diagnostics, diagnoseFiles(root, uri, s.Options.WorkspacePaths)...)
+		}
+	}
+	return diagnostics
+}
+
 // Handler for hover request
 // Selected keywords in `Keywords` are filled with documentations from databricks
 func (s *State) Hover(id int, uri string, position lsp.Position, logger *log.Logger) (lsp.HoverResponse, error) {
 	document := s.Documents[uri]
 
+	// Values explained from what they are, e.g. a cron expression, or from the workflow or file they name
+	explained, found := hoverValue(document, position)
+	if !found {
+		explained, found = s.hoverRunJob(uri, position)
+	}
+	if !found {
+		explained, found = s.hoverInclude(uri, position)
+	}
+	if found {
+		return lsp.HoverResponse{
+			Response: lsp.Response{
+				RPC: "2.0",
+				ID:  &id,
+			},
+			Result: lsp.HoverResult{
+				Contents: explained,
+			},
+		}, nil
+	}
+
 	line := strings.Split(document, "\n")[position.Line]
 
 	word, err := wordAtCursor(line, position, logger)
@@ -95,30 +151,21 @@ func (s *State) Hover(id int, uri string, position lsp.Position, logger *log.Log
 }
 
 // Handler for go to definition request
-// Parse the document and find where a task or a cluster is defined
-// For task, it needs a `description` right below the `task_key` to be discovered
+// Parse the document and find where a task or a cluster is defined, see `indexReferences`,
+// the workflow of a `job_name`, an included file or the anchor of an alias
 func (s *State) Definition(id int, uri string, position lsp.Position, logger *log.Logger) (lsp.DefinitionResponse, error) {
 	document := s.Documents[uri]
-	lines := strings.Split(document, "\n")
-	item_name, err := wordAtCursor(lines[position.Line], position, logger)
-	if err != nil {
-		return lsp.DefinitionResponse{}, err
-	}
-	if item_name == "" {
-		return lsp.DefinitionResponse{}, errors.New("Not a word")
-	}
-	matched, err := regexp.MatchString("job_cluster_key:|task_key:", lines[position.Line])
-	if err != nil {
-		return lsp.DefinitionResponse{}, err
+	location, found := s.runJobDefinition(uri, position)
+	if anchor, isAlias := aliasDefinition(document, position); isAlias && !found {
+		location, found = lsp.Location{URI: uri, Range: anchor}, true
 	}
-	if !matched {
-		return lsp.DefinitionResponse{}, errors.New("Not task or cluster")
-	}
-
-	item := findDefinition(document, item_name, logger)
-
-	if item.defined == lsp.LineRange(0, 0, 0) {
-		return lsp.DefinitionResponse{}, errors.New("Not defined")
+	if !found {
+		defined, err := findDefinition(document, position, logger)
+		if err == nil {
+			location = lsp.Location{URI: uri, Range: defined}
+		} else if location, found = s.includeDefinition(uri, position); !found {
+			return lsp.DefinitionResponse{}, err
+		}
 	}
 
 	// Definition response
@@ -127,21 +174,24 @@ func (s *State) Definition(id int, uri string, position lsp.Position, logger *lo
 			RPC: "2.0",
 			ID:  &id,
 		},
-		Result: lsp.Location{
-			URI:   uri,
-			Range: item.defined,
-		},
+		Result: location,
 	}
 
 	return response, nil
 }
 
 // Handler for code action request
-// For now it does the same thing as the simplest format
-func (s *State) CodeAction(id int, uri string, logger *log.Logger) (lsp.CodeActionResponse, error) {
+// It removes trailing whitespaces, like the simplest format,
+// and fixes a misspelled `timezone_id` when it is in the requested range
+func (s *State) CodeAction(id int, uri string, selection lsp.Range, logger *log.Logger) (lsp.CodeActionResponse, error) {
 	document := s.Documents[uri]
 
 	actions := []lsp.CodeAction{}
+	if root, err := yaml.Parse(document); err == nil {
+		if fix := fixTimeZone(root, uri); fix != nil && overlaps(fix.Edit.Changes[uri][0].Range, selection) {
+			actions = append(actions, *fix)
+		}
+	}
 	re, err := regexp.Compile("\\s+$")
 	if err != nil {
 		logger.Printf("CodeAction Regexp Compile %s", err)
@@ -177,79 +227,39 @@ func (s *State) CodeAction(id int, uri string, logger *log.Logger) (lsp.CodeActi
 }
 
 // Handler for format request
-// It can insert spaces, trim whitespaces and trailing new lines
+// The document is rewritten from its tree, see `workflow.Format`, then the options are applied
+// A document that doesn't parse only gets the options, the edits only cover what changed
 func (s *State) DocumentFormatting(id int, uri string, opts lsp.FormattingOptions, logger *log.Logger) (lsp.DocumentFormattingResponse, error) {
 	document := s.Documents[uri]
 
-	// Note: No need to do tabs for yaml, apparently. But I wrote it so I'm keeping it
-	tabs := strings.Repeat(" ", opts.TabSize)
-	trimTrailingWhitespace, trimFinalNewlines := true, true
-	insertSpace, trimTrailingWhitespace, trimFinalNewlines := true, true, true
-	if opts.InsertSpaces != nil {
-		insertSpace = *opts.InsertSpaces
-	}
-	if opts.TrimTrailingWhitespace != nil {
-		trimTrailingWhitespace = *opts.TrimTrailingWhitespace
-	}
-	if opts.TrimFinalNewlines != nil {
-		trimFinalNewlines = *opts.TrimFinalNewlines
-	}
-
-	edits := []lsp.TextEdit{}
-	re, err := regexp.Compile("\n+$")
+	formatted, err := workflow.Format(document, workflow.TaskOrder(s.Options.TaskOrder))
 	if err != nil {
-		logger.Printf("Formatting Regexp Compile %s", err)
-		return lsp.DocumentFormattingResponse{}, err
-	}
-	loc := re.FindStringIndex(document)
-	if loc != nil && trimFinalNewlines {
-		trim := loc[1] - loc[0] + 1
-		lines := strings.Split(document, "\n")
-		rows := len(lines)
-		trimFinalNewlinesEdit := lsp.TextEdit{
-			Range: lsp.Range{
-				Start: lsp.Position{
-					Line:      rows - trim,
-					Character: len(lines[rows-trim]),
-				},
-				End: lsp.Position{
-					Line:      rows - 1,
-					Character: 0,
-				},
-			},
-			NewText: "",
-		}
-		edits = append(edits, trimFinalNewlinesEdit)
+		logger.Printf("Formatting %s", err)
+		formatted = document
 	}
+	formatted = applyFormattingOptions(formatted, document, opts)
 
-	re, err = regexp.Compile("\\s+$")
-	if err != nil {
-		logger.Printf("Formatting Regexp Compile %s", err)
-		return lsp.DocumentFormattingResponse{}, err
+	// Formatting response
+	response := lsp.DocumentFormattingResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: textEdits(document, formatted),
 	}
-	reTab, err := regexp.Compile("\\t")
+
+	return response, nil
+}
+
+// Handler for range format request
+// The selected lines are formatted on their own, e.g. a pasted block
+func (s *State) DocumentRangeFormatting(id int, uri string, selection lsp.Range, logger *log.Logger) (lsp.DocumentFormattingResponse, error) {
+	document := s.Documents[uri]
+
+	edits, err := formatRange(document, selection)
 	if err != nil {
-		logger.Printf("Formatting Regexp Compile %s", err)
-		return lsp.DocumentFormattingResponse{}, err
-	}
-	for row, line := range strings.Split(document, "\n") {
-		loc := re.FindStringIndex(line)
-		if loc != nil && trimTrailingWhitespace {
-			edits = append(edits, lsp.TextEdit{
-				Range:   lsp.LineRange(row, loc[0], loc[1]),
-				NewText: "",
-			})
-		}
-		locs := reTab.FindAllStringIndex(line, -1)
-		if locs != nil && insertSpace {
-			logger.Print("Found some open tabs")
-			for _, loc := range locs {
-				edits = append(edits, lsp.TextEdit{
-					Range:   lsp.LineRange(row, loc[0], loc[1]),
-					NewText: tabs,
-				})
-			}
-		}
+		logger.Printf("Range formatting %s", err)
+		edits = []lsp.TextEdit{}
 	}
 
 	// Formatting response
@@ -264,6 +274,219 @@ func (s *State) DocumentFormatting(id int, uri string, opts lsp.FormattingOption
 	return response, nil
 }
 
+// Handler for on type format request
+// After a new line, the line is indented for what comes next
+func (s *State) DocumentOnTypeFormatting(id int, uri string, position lsp.Position, ch string) lsp.DocumentFormattingResponse {
+	document := s.Documents[uri]
+
+	// Formatting response
+	response := lsp.DocumentFormattingResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: formatOnType(document, position, ch),
+	}
+
+	return response
+}
+
+// Handler for folding range request
+// Tasks, clusters, long blocks and comments can be folded
+func (s *State) FoldingRange(id int, uri string) lsp.FoldingRangeResponse {
+	document := s.Documents[uri]
+
+	// Folding range response
+	response := lsp.FoldingRangeResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: foldingRanges(document),
+	}
+
+	return response
+}
+
+// Handler for selection range request
+// Selections expand along the tree, e.g. from a value to its task, the tasks and the document
+func (s *State) SelectionRange(id int, uri string, positions []lsp.Position) lsp.SelectionRangeResponse {
+	document := s.Documents[uri]
+
+	// Only the whole document is selected when it doesn't parse
+	root, _ := yaml.Parse(document)
+	ranges := []lsp.SelectionRange{}
+	for _, position := range positions {
+		ranges = append(ranges, selectionRange(document, root, position))
+	}
+
+	// Selection range response
+	response := lsp.SelectionRangeResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: ranges,
+	}
+
+	return response
+}
+
+// Handler for semantic tokens request
+// `within` limits the tokens to the lines of a range, nil for the whole document
+func (s *State) SemanticTokens(id int, uri string, within *lsp.Range) lsp.SemanticTokensResponse {
+	document := s.Documents[uri]
+
+	// Semantic tokens response
+	response := lsp.SemanticTokensResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: documentSemanticTokens(document, within),
+	}
+
+	return response
+}
+
+// Handler for inlay hint request
+// Hints only cover the lines of the requested range
+func (s *State) InlayHint(id int, uri string, within lsp.Range) lsp.InlayHintResponse {
+	document := s.Documents[uri]
+
+	hints := []lsp.InlayHint{}
+	if root, err := yaml.Parse(document); err == nil {
+		hints = inlayHints(root, within)
+	}
+
+	// Inlay hint response
+	response := lsp.InlayHintResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: hints,
+	}
+
+	return response
+}
+
+// Handler for prepare rename request
+// Only anchors and aliases can be renamed, elsewhere the result is null
+func (s *State) PrepareRename(id int, uri string, position lsp.Position) lsp.PrepareRenameResponse {
+	document := s.Documents[uri]
+
+	// Prepare rename response
+	response := lsp.PrepareRenameResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: prepareRename(document, position),
+	}
+
+	return response
+}
+
+// Handler for rename request
+// Renames an anchor and the aliases referring to it, from either of them
+func (s *State) Rename(id int, uri string, position lsp.Position, name string) (lsp.RenameResponse, error) {
+	document := s.Documents[uri]
+	edit, err := renameAnchor(document, uri, position, name)
+	if err != nil {
+		return lsp.RenameResponse{}, err
+	}
+
+	// Rename response
+	response := lsp.RenameResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: edit,
+	}
+
+	return response, nil
+}
+
+// Handler for references request
+// Uses of a task or job cluster key, see `indexReferences`
+func (s *State) References(id int, uri string, position lsp.Position, declarations bool) lsp.ReferencesResponse {
+	document := s.Documents[uri]
+
+	// References response
+	response := lsp.ReferencesResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: findReferences(document, uri, position, declarations),
+	}
+
+	return response
+}
+
+// Handler for code lens request
+// Lenses are computed from whatever parses, a broken document has none
+func (s *State) CodeLens(id int, uri string) lsp.CodeLensResponse {
+	document := s.Documents[uri]
+
+	lenses := []lsp.CodeLens{}
+	if root, err := yaml.Parse(document); err == nil {
+		lenses = codeLenses(root, uri)
+	}
+
+	// Code lens response
+	response := lsp.CodeLensResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: lenses,
+	}
+
+	return response
+}
+
+// Handler for document highlight request
+// The declaration and the uses of the task or job cluster under the cursor
+func (s *State) DocumentHighlight(id int, uri string, position lsp.Position) lsp.DocumentHighlightResponse {
+	document := s.Documents[uri]
+
+	// Document highlight response
+	response := lsp.DocumentHighlightResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: documentHighlights(document, position),
+	}
+
+	return response
+}
+
+// Handler for document link request
+// Paths open the local file they refer to, keys open their documentation
+func (s *State) DocumentLink(id int, uri string) lsp.DocumentLinkResponse {
+	document := s.Documents[uri]
+
+	links := []lsp.DocumentLink{}
+	if root, err := yaml.Parse(document); err == nil {
+		links = documentLinks(root, uri, s.Options.WorkspacePaths)
+	}
+
+	// Document link response
+	response := lsp.DocumentLinkResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: links,
+	}
+
+	return response
+}
+
 // Handler for completion request
 // Selected keywords in `Keywords` are filled with examples
 func (s *State) Completion(id int, uri string, position lsp.Position, logger *log.Logger) (lsp.CompletionResponse, error) {
@@ -271,17 +494,23 @@ func (s *State) Completion(id int, uri string, position lsp.Position, logger *lo
 
 	items := []lsp.CompletionItem{}
 	line := strings.Split(document, "\n")[position.Line]
-	word, err := wordAtCursor(line, position, logger)
-	if err != nil {
-		return lsp.CompletionResponse{}, err
-	}
 
-	leading, err := leadingSpaces(line, logger)
-	if err != nil {
-		return lsp.CompletionResponse{}, err
-	}
+	// Values with a known set of choices
+	if values := completeTimeZone(line, position); len(values) > 0 {
+		items = append(items, values...)
+	} else {
+		word, err := wordAtCursor(line, position, logger)
+		if err != nil {
+			return lsp.CompletionResponse{}, err
+		}
+
+		leading, err := leadingSpaces(line, logger)
+		if err != nil {
+			return lsp.CompletionResponse{}, err
+		}
 
-	items = append(items, complete(word, leading)...)
+		items = append(items, complete(word, leading)...)
+	}
 
 	// Completion response
 	response := lsp.CompletionResponse{
@@ -297,3 +526,28 @@ func (s *State) Completion(id int, uri string, position lsp.Position, logger *lo
 
 	return response, nil
 }
+
+// Handler for execute command request
+// Commands are listed in `Commands`, they may also return an edit to apply to the workspace
+func (s *State) ExecuteCommand(id int, params lsp.ExecuteCommandParams, logger *log.Logger) (lsp.ExecuteCommandResponse, *lsp.WorkspaceEdit, error) {
+	command, found := Commands[params.Command]
+	if !found {
+		return lsp.ExecuteCommandResponse{}, nil, fmt.Errorf("Unknown command %s", params.Command)
+	}
+
+	result, edit, err := command(s, params.Arguments, logger)
+	if err != nil {
+		return lsp.ExecuteCommandResponse{}, nil, fmt.Errorf("%s: %s", params.Command, err)
+	}
+
+	// Execute command response
+	response := lsp.ExecuteCommandResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: result,
+	}
+
+	return response, edit, nil
+}
@@ -378,7 +378,7 @@ var Keywords = map[string]Keyword{
 				"Example",
 				"```yaml",
 				"schedule:",
-				"  quartz_cronz_expression: \"0 0 0 * * ?\"",
+				"  quartz_cron_expression: \"0 0 0 * * ?\"",
 				"  timezone_id: \"UTC\"",
 				"  pause_status: `\"PAUSED\"`",
 				"```",
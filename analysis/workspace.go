@@ -0,0 +1,178 @@
+package analysis
+
+import (
+	"dbwf-ls/lsp"
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Workflow files of a workspace
+const workflowSuffix = ".flow.yaml"
+
+// Folders never holding workflows of the project, hidden folders are skipped too
+var skippedFolders = []string{"node_modules", "venv", "__pycache__"}
+
+// Index every `.flow.yaml` under the folders of the workspace, given as uris, a file given instead of a folder is indexed alone
+// It may run in the background, files the watcher reported meanwhile are newer and kept
+func (s *State) IndexWorkspace(folders []string, logger *log.Logger) {
+	indexed := map[string]string{}
+	for _, folder := range folders {
+		root := uriPath(folder)
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				logger.Printf("Indexing %s: %s", path, err)
+				return nil
+			}
+			if entry.IsDir() {
+				if path != root && (strings.HasPrefix(entry.Name(), ".") || slices.Contains(skippedFolders, entry.Name())) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, workflowSuffix) {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					logger.Printf("Indexing %s: %s", path, err)
+					return nil
+				}
+				indexed[fileURI(path)] = string(content)
+			}
+			return nil
+		})
+	}
+
+	s.workspaceLock.Lock()
+	defer s.workspaceLock.Unlock()
+	for uri, content := range indexed {
+		if _, found := s.Workspace[uri]; !found {
+			s.Workspace[uri] = content
+		}
+	}
+	logger.Printf("Indexed %d workflows", len(s.Workspace))
+}
+
+// Handler for when watched files changed
+// Created and changed files are read again, deleted files leave the index
+func (s *State) ChangeWatchedFiles(changes []lsp.FileEvent, logger *log.Logger) {
+	s.workspaceLock.Lock()
+	defer s.workspaceLock.Unlock()
+	for _, change := range changes {
+		if !strings.HasSuffix(change.URI, workflowSuffix) {
+			continue
+		}
+		if change.Type == 3 {
+			delete(s.Workspace, change.URI)
+			continue
+		}
+		s.indexFile(change.URI, logger)
+	}
+}
+
+func (s *State) indexFile(uri string, logger *log.Logger) {
+	content, err := os.ReadFile(uriPath(uri))
+	if err != nil {
+		logger.Printf("Indexing %s: %s", uri, err)
+		delete(s.Workspace, uri)
+		return
+	}
+	s.Workspace[uri] = string(content)
+}
+
+// Every workflow known by the server, the documents open in the editor over their version on disk
+func (s *State) workflows() map[string]string {
+	workflows := map[string]string{}
+	s.workspaceLock.Lock()
+	for uri, document := range s.Workspace {
+		workflows[uri] = document
+	}
+	s.workspaceLock.Unlock()
+	for uri, document := range s.Documents {
+		workflows[uri] = document
+	}
+	return workflows
+}
+
+// Handler for workspace symbol request
+// Jobs by name and their tasks and job clusters by key, from every workflow of the workspace
+func (s *State) WorkspaceSymbol(id int, query string) lsp.WorkspaceSymbolResponse {
+	workflows := s.workflows()
+	uris := []string{}
+	for uri := range workflows {
+		uris = append(uris, uri)
+	}
+	slices.Sort(uris)
+
+	symbols := []lsp.SymbolInformation{}
+	for _, uri := range uris {
+		root, err := yaml.Parse(workflows[uri])
+		if err != nil {
+			continue
+		}
+		for _, symbol := range workflowSymbols(root, uri) {
+			if fuzzyMatch(query, symbol.Name) {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	// Workspace symbol response
+	response := lsp.WorkspaceSymbolResponse{
+		Response: lsp.Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Result: symbols,
+	}
+
+	return response
+}
+
+// Job of a workflow, its tasks (nested `for_each_task` tasks included) and its job clusters
+// A job without a name is named after its file
+func workflowSymbols(root *yaml.Node, uri string) []lsp.SymbolInformation {
+	job := workflow.JobName(root, uriPath(uri))
+	symbols := []lsp.SymbolInformation{}
+	if name := root.Get("name"); name != nil && name.Kind == yaml.ScalarNode && name.Value != "" {
+		symbols = append(symbols, lsp.SymbolInformation{
+			Name:     job,
+			Kind:     2,
+			Location: lsp.Location{URI: uri, Range: nodeRange(name)},
+		})
+	}
+	for _, ref := range indexReferences(root) {
+		if !ref.definition {
+			continue
+		}
+		kind := 5
+		if ref.kind == "job_cluster" {
+			kind = 23
+		}
+		symbols = append(symbols, lsp.SymbolInformation{
+			Name:          ref.name,
+			Kind:          kind,
+			Location:      lsp.Location{URI: uri, Range: nodeRange(ref.node)},
+			ContainerName: job,
+		})
+	}
+	return symbols
+}
+
+// Whether the characters of a query appear in order in a name, ignoring case
+func fuzzyMatch(query, name string) bool {
+	name = strings.ToLower(name)
+	for _, r := range strings.ToLower(query) {
+		index := strings.IndexRune(name, r)
+		if index < 0 {
+			return false
+		}
+		name = name[index+utf8.RuneLen(r):]
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package analysis_test
+
+import (
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWorkspaceSymbol(t *testing.T) {
+	folder := t.TempDir()
+	files := map[string]string{
+		"jobs/nightly.flow.yaml":  "name: nightly\ntasks:\n  - task_key: load\n    job_cluster_key: big\njob_clusters:\n  - job_cluster_key: big\n",
+		"jobs/weekly.flow.yaml":   "tasks:\n  - task_key: report\n",
+		"jobs/notes.yaml":         "name: not a workflow\n",
+		".venv/lib/dep.flow.yaml": "name: hidden\n",
+	}
+	for file, content := range files {
+		os.MkdirAll(filepath.Join(folder, filepath.Dir(file)), 0o755)
+		os.WriteFile(filepath.Join(folder, file), []byte(content), 0o644)
+	}
+	uri := func(file string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.Join(folder, file)}).String()
+	}
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	state.IndexWorkspace([]string{uri("")}, logger)
+
+	names := func(query string) []string {
+		names := []string{}
+		for _, symbol := range state.WorkspaceSymbol(1, query).Result {
+			names = append(names, symbol.ContainerName+"/"+symbol.Name)
+		}
+		return names
+	}
+	if expected, actual := []string{"/nightly", "nightly/load", "nightly/big", "weekly/report"}, names(""); !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+	if expected, actual := []string{"nightly/load"}, names("LD"); !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+
+	// Open documents win over the disk, deleted files leave the index
+	state.OpenDocument(uri("jobs/weekly.flow.yaml"), "name: weekly\n", logger)
+	state.ChangeWatchedFiles([]lsp.FileEvent{{URI: uri("jobs/nightly.flow.yaml"), Type: 3}}, logger)
+	if expected, actual := []string{"/weekly"}, names(""); !slices.Equal(expected, actual) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestIndexWorkspaceBackground(t *testing.T) {
+	folder := t.TempDir()
+	for i := range 50 {
+		os.WriteFile(filepath.Join(folder, fmt.Sprintf("job%d.flow.yaml", i)), []byte("tasks:\n  - task_key: load\n"), 0o644)
+	}
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	indexed := make(chan bool)
+	go func() {
+		state.IndexWorkspace([]string{(&url.URL{Scheme: "file", Path: folder}).String()}, logger)
+		close(indexed)
+	}()
+
+	// Requests keep being answered while the workspace is indexed
+	uri := (&url.URL{Scheme: "file", Path: filepath.Join(folder, "job0.flow.yaml")}).String()
+	state.ChangeWatchedFiles([]lsp.FileEvent{{URI: uri, Type: 2}}, logger)
+	state.WorkspaceSymbol(1, "load")
+	<-indexed
+
+	if expected, actual := 50, len(state.WorkspaceSymbol(1, "load").Result); expected != actual {
+		t.Fatalf("Expected: %d, Actual: %d", expected, actual)
+	}
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const bundleUsage = `Usage: dbwf-ls bundle export [flags] <file.flow.yaml|->
+       dbwf-ls bundle import [flags] <databricks.yml|->
+`
+
+// `dbwf-ls bundle export|import`
+// Move a workflow to a Databricks Asset Bundle resource file and back
+func bundle(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, bundleUsage)
+		return 2
+	}
+	switch args[0] {
+	case "export":
+		return bundleExport(args[1:], stdout, stderr)
+	case "import":
+		return bundleImport(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "Unknown bundle command %q\n\n%s", args[0], bundleUsage)
+		return 2
+	}
+}
+
+// Write the workflow as `resources.jobs.<key>`, the key defaults to the name of the file
+func bundleExport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("bundle export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	key := flags.String("key", "", "key of the job under `resources.jobs`, defaults to the name of the file")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	jobIDs := jobFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls bundle export [flags] <file.flow.yaml|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+	content, err := readInput(file)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if _, ok := loadSettings(file, content, stderr); !ok {
+		return 1
+	}
+	root, _ := yaml.Parse(string(content))
+	root, _, _ = workflow.ResolveIncludes(root, file, readText)
+	if err := workflow.ResolveJobNameNodes(root, func(name string) (int64, error) {
+		return runJobID(file, name, jobIDs)
+	}); err != nil {
+		fmt.Fprintf(stderr, "%s: error: %s\n", file, err)
+		return 1
+	}
+	if *key == "" {
+		*key = workflow.BundleKey(file)
+	}
+
+	document := yaml.Encode(workflow.Bundle(root, *key))
+	if err := writeOutput(*output, stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, document)
+		return err
+	}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// Write a job of `resources.jobs` as a `.flow.yaml`
+func bundleImport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("bundle import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	job := flags.String("job", "", "key of the job under `resources.jobs`, required when the bundle has more than one")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls bundle import [flags] <databricks.yml|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+	content, err := readInput(file)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	root, err := yaml.Parse(string(content))
+	if err != nil {
+		var syntaxError *yaml.Error
+		if errors.As(err, &syntaxError) {
+			fmt.Fprintf(stderr, "%s:%d:%d: error: %s\n", file, syntaxError.Line+1, syntaxError.Column+1, syntaxError.Message)
+		} else {
+			fmt.Fprintf(stderr, "%s: error: %s\n", file, err)
+		}
+		return 1
+	}
+
+	workflows, problems := workflow.FromBundle(root)
+	for _, problem := range problems {
+		fmt.Fprintf(stderr, "%s:%d:%d: error: %s\n", file, problem.Line+1, problem.Column+1, problem.Message)
+	}
+	keys := []string{}
+	for key := range workflows {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	if *job == "" {
+		if len(keys) != 1 {
+			fmt.Fprintf(stderr, "%s: error: choose a job with --job, found: %s\n", file, strings.Join(keys, ", "))
+			return 1
+		}
+		*job = keys[0]
+	}
+	selected, found := workflows[*job]
+	if !found {
+		fmt.Fprintf(stderr, "%s: error: no job `%s` in `resources.jobs`, found: %s\n", file, *job, strings.Join(keys, ", "))
+		return 1
+	}
+
+	document := yaml.Encode(selected)
+	if err := writeOutput(*output, stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, document)
+		return err
+	}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
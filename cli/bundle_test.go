@@ -0,0 +1,50 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"clean.flow.yaml": clean})
+	file := filepath.Join(dir, "clean.flow.yaml")
+	exported := filepath.Join(dir, "databricks.yml")
+
+	code, _, stderr := run("bundle", "export", "-o", exported, file)
+	if code != 0 {
+		t.Fatalf("Expected: 0, Actual: %d %s", code, stderr)
+	}
+	content, err := os.ReadFile(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "resources:\n  jobs:\n    clean:\n      name: clean\n") || !strings.Contains(string(content), "level: CAN_MANAGE") {
+		t.Fatalf("Expected: the job under resources.jobs.clean, Actual: %s", content)
+	}
+
+	code, output, stderr := run("bundle", "import", exported)
+	if code != 0 || clean != output {
+		t.Fatalf("Expected: 0 %s, Actual: %d %s %s", clean, code, output, stderr)
+	}
+
+	if code, _, _ := run("bundle", "publish", file); code != 2 {
+		t.Fatalf("Expected: 2, Actual: %d", code)
+	}
+}
+
+func TestBundleJobNames(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"trigger.flow.yaml": "name: trigger\ntasks:\n  - task_key: run\n    run_job_task:\n      job_name: \"nightly\"\n"})
+	file := filepath.Join(dir, "trigger.flow.yaml")
+
+	code, output, stderr := run("bundle", "export", "--job", "nightly=42", file)
+	if expected := "          run_job_task:\n            job_id: 42\n"; code != 0 || !strings.HasSuffix(output, expected) {
+		t.Fatalf("Expected: 0 ...%s, Actual: %d %s %s", expected, code, output, stderr)
+	}
+
+	expected := file + ": error: job `nightly` of `run_job_task`: no id, pass `--job nightly=<id>`\n"
+	if code, output, stderr := run("bundle", "export", file); code != 1 || output != "" || expected != stderr {
+		t.Fatalf("Expected: 1 %s, Actual: %d %s", expected, code, stderr)
+	}
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"cmp"
+	"dbwf-ls/analysis"
+	"dbwf-ls/lsp"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Names of the diagnostic severities, indexed by the LSP severity
+var severityNames = []string{"", "error", "warning", "information", "hint"}
+
+// Diagnostics of a single file
+type fileReport struct {
+	file        string
+	diagnostics []lsp.Diagnostics
+}
+
+// `dbwf-ls check [flags] <file|dir|glob>...`
+// Run the same diagnostics as the editor and exit non-zero
+// when any of them is at least as severe as `--fail-on`
+func check(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "text", "output format: text, json, sarif, junit or github")
+	failOn := flags.String("fail-on", "error", "lowest severity that fails the check: error, warning, information, hint or none")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls check [flags] <file|dir|glob>...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	threshold := 0
+	if *failOn != "none" {
+		threshold = slices.Index(severityNames, *failOn)
+		if threshold < 1 {
+			fmt.Fprintf(stderr, "Unknown severity %q\n", *failOn)
+			return 2
+		}
+	}
+	writeReport, found := reportWriters[*format]
+	if !found {
+		fmt.Fprintf(stderr, "Unknown format %q\n", *format)
+		return 2
+	}
+
+	files, err := expandPaths(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(stderr, "No files to check")
+		return 2
+	}
+
+	// The `job_name` of a `run_job_task` is looked up like in the editor, among the workflows of the working directory
+	// and the checked files
+	logger := log.New(io.Discard, "", 0)
+	state := analysis.NewState()
+	state.IndexWorkspace(workspace(files), logger)
+	reports := []fileReport{}
+	failed := false
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		diagnostics := state.Diagnose(file, string(content), logger)
+		slices.SortStableFunc(diagnostics, func(a, b lsp.Diagnostics) int {
+			if c := cmp.Compare(a.Range.Start.Line, b.Range.Start.Line); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.Range.Start.Character, b.Range.Start.Character)
+		})
+		for _, diagnostic := range diagnostics {
+			if failing(diagnostic, threshold) {
+				failed = true
+			}
+		}
+		reports = append(reports, fileReport{file: file, diagnostics: diagnostics})
+	}
+
+	if err := writeReport(stdout, reports, threshold); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// Uris of the working directory and of the checked files, as the folders of a workspace
+func workspace(files []string) []string {
+	paths := append([]string{"."}, files...)
+	uris := []string{}
+	for _, path := range paths {
+		if absolute, err := filepath.Abs(path); err == nil {
+			uris = append(uris, (&url.URL{Scheme: "file", Path: absolute}).String())
+		}
+	}
+	return uris
+}
+
+// Whether a diagnostic fails the check, a threshold of 0 never fails
+func failing(diagnostic lsp.Diagnostics, threshold int) bool {
+	return threshold > 0 && diagnostic.Severity >= 1 && diagnostic.Severity <= threshold
+}
+
+func severityName(severity int) string {
+	if severity < 1 || severity >= len(severityNames) {
+		return "error"
+	}
+	return severityNames[severity]
+}
@@ -0,0 +1,163 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Workflow depending on an undeclared task, an error on line 29
+var broken = strings.Replace(clean, "    description: Ingest the data\n", "    description: Ingest the data\n    depends_on:\n      - task_key: missing\n", 1)
+
+// Workflow without tags, a warning
+var untagged = strings.Replace(clean, "tags:\n  team: data\n", "", 1)
+
+func TestCheckExitCodes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"clean.flow.yaml":    clean,
+		"broken.flow.yaml":   broken,
+		"untagged.flow.yaml": untagged,
+		"empty/other.yaml":   clean,
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{path("clean.flow.yaml")}, 0},
+		{[]string{path("broken.flow.yaml")}, 1},
+		{[]string{path("clean.flow.yaml"), path("broken.flow.yaml")}, 1},
+		{[]string{path("untagged.flow.yaml")}, 0},
+		{[]string{"--fail-on", "warning", path("untagged.flow.yaml")}, 1},
+		{[]string{"--fail-on", "hint", path("clean.flow.yaml")}, 0},
+		{[]string{"--fail-on", "none", path("broken.flow.yaml")}, 0},
+		{[]string{"--fail-on", "fatal", path("clean.flow.yaml")}, 2},
+		{[]string{"--format", "html", path("clean.flow.yaml")}, 2},
+		{[]string{path("missing.flow.yaml")}, 2},
+		{[]string{path("empty")}, 2},
+		{[]string{}, 2},
+	}
+	for _, test := range tests {
+		code, _, stderr := run(append([]string{"check"}, test.args...)...)
+		if code != test.code {
+			t.Fatalf("%v, Expected: %d, Actual: %d %s", test.args, test.code, code, stderr)
+		}
+	}
+}
+
+func TestCheckFormats(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"broken.flow.yaml": broken})
+	file := filepath.Join(dir, "broken.flow.yaml")
+	message := "`missing` is not declared but used in at least 1 task."
+
+	expected := map[string]string{
+		"text":   fmt.Sprintf("%s:29:19: error: %s\n", file, message),
+		"github": fmt.Sprintf("::error file=%s,line=29,col=19,endLine=29,endColumn=19,title=dbwf-ls::%s\n", file, message),
+	}
+	for format, expected := range expected {
+		code, actual, _ := run("check", "--format", format, file)
+		if code != 1 || expected != actual {
+			t.Fatalf("%s, Expected: 1 %s, Actual: %d %s", format, expected, code, actual)
+		}
+	}
+
+	_, output, _ := run("check", "--format", "json", file)
+	var diagnostics []struct {
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(output), &diagnostics); err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].File != file || diagnostics[0].Line != 29 || diagnostics[0].Column != 19 ||
+		diagnostics[0].Severity != "error" || diagnostics[0].Message != message {
+		t.Fatalf("json, Expected: %s:29:19 error %s, Actual: %+v", file, message, diagnostics)
+	}
+
+	_, output, _ = run("check", "--format", "sarif", file)
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(output), &sarif); err != nil {
+		t.Fatal(err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 1 {
+		t.Fatalf("sarif, Expected: a single result, Actual: %s", output)
+	}
+	location := sarif.Runs[0].Results[0].Locations[0].PhysicalLocation
+	if sarif.Runs[0].Results[0].Level != "error" || location.ArtifactLocation.URI != filepath.ToSlash(file) || location.Region.StartLine != 29 {
+		t.Fatalf("sarif, Expected: error at %s:29, Actual: %s", file, output)
+	}
+
+	// Warnings below the threshold are written out, not failed
+	dir = writeFiles(t, map[string]string{"broken.flow.yaml": broken, "untagged.flow.yaml": untagged})
+	_, output, _ = run("check", "--format", "junit", filepath.Join(dir, "broken.flow.yaml"), filepath.Join(dir, "untagged.flow.yaml"))
+	var junit struct {
+		Suites []struct {
+			Tests    int `xml:"tests,attr"`
+			Failures int `xml:"failures,attr"`
+			Cases    []struct {
+				Name      string   `xml:"name,attr"`
+				Failures  []string `xml:"failure"`
+				SystemOut string   `xml:"system-out"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal([]byte(output), &junit); err != nil {
+		t.Fatal(err)
+	}
+	suite := junit.Suites[0]
+	if suite.Tests != 2 || suite.Failures != 1 || len(suite.Cases[0].Failures) != 1 || len(suite.Cases[1].Failures) != 0 {
+		t.Fatalf("junit, Expected: 2 tests and 1 failure, Actual: %s", output)
+	}
+	if !strings.Contains(suite.Cases[1].SystemOut, "warning: `tags` is missing") {
+		t.Fatalf("junit, Expected: the warning in system-out, Actual: %s", suite.Cases[1].SystemOut)
+	}
+}
+
+func TestCheckRunJobs(t *testing.T) {
+	trigger := clean + `  - task_key: nightly
+    description: Run the nightly job
+    run_job_task:
+      job_name: nightly
+      job_parameters:
+        region: eu
+  - task_key: weekly
+    description: Run the weekly job
+    run_job_task:
+      job_name: weekly
+`
+	nightly := strings.Replace(clean, "name: clean\n", "name: nightly\nparameters:\n  - name: date\n    default: today\n", 1)
+	dir := writeFiles(t, map[string]string{"trigger.flow.yaml": trigger, "jobs/nightly.flow.yaml": nightly})
+	file := filepath.Join(dir, "trigger.flow.yaml")
+
+	// The other workflows are found among the checked files, like in the workspace of the editor
+	code, output, stderr := run("check", "--fail-on", "warning", file, filepath.Join(dir, "jobs"))
+	expected := fmt.Sprintf("%s:35:9: warning: `region` is not a parameter of `nightly`, expected one of `date`.\n", file) +
+		fmt.Sprintf("%s:39:17: error: Workflow `weekly` is not in the workspace.\n", file)
+	if code != 1 || expected != output {
+		t.Fatalf("Expected: 1 %s, Actual: %d %s %s", expected, code, output, stderr)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Usage of the binary when it is not used as a language server
+const usage = `Usage: dbwf-ls [command] [flags]
+
+Without a command, or with flags like --stdio, dbwf-ls speaks LSP over stdio.
+
+Commands:
+  check    Run the editor diagnostics over files, directories or globs
+  convert  Write the Jobs API request body of a workflow
+  import   Write the .flow.yaml of a job exported as JSON
+  bundle   Export a workflow as a Databricks Asset Bundle resource, or import it back
+  export   Write a workflow as a Terraform resource
+  graph    Write the task dependency graph as Mermaid or DOT
+  diff     Compare two versions of a workflow by tasks, clusters and permissions
+  help     Show this message
+`
+
+var commands = []string{"check", "convert", "import", "bundle", "export", "graph", "diff", "help", "-h", "--help"}
+
+// Whether an argument names a subcommand
+// Anything else, like the `--stdio` flag that clients pass, is left to the language server
+func IsCommand(name string) bool {
+	return slices.Contains(commands, name)
+}
+
+// Run a subcommand and return the exit code of the process
+// `args` are the command line arguments without the program name
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "check":
+		return check(args[1:], stdout, stderr)
+	case "convert":
+		return convert(args[1:], stdout, stderr)
+	case "import":
+		return importJob(args[1:], stdout, stderr)
+	case "bundle":
+		return bundle(args[1:], stdout, stderr)
+	case "export":
+		return export(args[1:], stdout, stderr)
+	case "graph":
+		return graph(args[1:], stdout, stderr)
+	case "diff":
+		return diff(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
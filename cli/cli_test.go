@@ -0,0 +1,103 @@
+package cli_test
+
+import (
+	"dbwf-ls/cli"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Workflow without any diagnostic
+const clean = `name: clean
+description: Clean workflow
+tags:
+  team: data
+timeout_seconds: 3600
+max_concurrent_runs: 1
+health:
+  rules:
+    - metric: RUN_DURATION_SECONDS
+      op: GREATER_THAN
+      value: 1800
+email_notifications:
+  on_failure:
+    - team@example.com
+notification_settings:
+  no_alert_for_skipped_runs: true
+schedule:
+  quartz_cron_expression: "0 0 6 * * ?"
+  timezone_id: UTC
+run_as:
+  user_name: someone@example.com
+access_control_list:
+  - user_name: someone@example.com
+    permission_level: CAN_MANAGE
+tasks:
+  - task_key: ingest
+    description: Ingest the data
+    notebook_task:
+      notebook_path: /Shared/ingest
+`
+
+// Write files under a temporary directory and return the directory
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Exit code, stdout and stderr of a command
+func run(args ...string) (int, string, string) {
+	var stdout, stderr strings.Builder
+	code := cli.Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{}, 2},
+		{[]string{"help"}, 0},
+		{[]string{"--help"}, 0},
+		{[]string{"lint"}, 2},
+		{[]string{"check"}, 2},
+		{[]string{"convert"}, 2},
+		{[]string{"check", "--unknown", "file"}, 2},
+	}
+	for _, test := range tests {
+		if code, _, _ := run(test.args...); code != test.code {
+			t.Fatalf("%v, Expected: %d, Actual: %d", test.args, test.code, code)
+		}
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	tests := map[string]bool{
+		"check":   true,
+		"convert": true,
+		"diff":    true,
+		"help":    true,
+		"--stdio": false,
+		"-v":      false,
+		"--help":  true,
+		"-h":      true,
+		"serve":   false,
+		"":        false,
+	}
+	for name, expected := range tests {
+		if actual := cli.IsCommand(name); expected != actual {
+			t.Fatalf("%q, Expected: %t, Actual: %t", name, expected, actual)
+		}
+	}
+}
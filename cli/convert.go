@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// `dbwf-ls convert [flags] <file.flow.yaml>`
+// Write the `jobs/create` request body, or the `jobs/reset` one when `--job-id` is given
+func convert(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jobID := flags.Int64("job-id", 0, "write a `jobs/reset` request for this job instead of a `jobs/create` one")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	jobIDs := jobFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls convert [flags] <file.flow.yaml|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+	content, err := readInput(file)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	settings, ok := loadSettings(file, content, stderr)
+	if !ok {
+		return 1
+	}
+
+	if err := workflow.ResolveJobNames(settings, func(name string) (int64, error) {
+		return runJobID(file, name, jobIDs)
+	}); err != nil {
+		fmt.Fprintf(stderr, "%s: error: %s\n", file, err)
+		return 1
+	}
+
+	request := workflow.CreateRequest(settings)
+	if *jobID != 0 {
+		request = workflow.ResetRequest(*jobID, settings)
+	}
+
+	if err := writeOutput(*output, stdout, func(w io.Writer) error {
+		return workflow.WriteJSON(w, request)
+	}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// Parse and convert a workflow with its aliases expanded and the files it includes,
+// problems are reported as `file:line:col: error: message`
+func loadSettings(file string, content []byte, stderr io.Writer) (workflow.Object, bool) {
+	root, err := yaml.Parse(string(content))
+	if err == nil {
+		root, err = yaml.Expand(root)
+	}
+	if err != nil {
+		var syntaxError *yaml.Error
+		if errors.As(err, &syntaxError) {
+			fmt.Fprintf(stderr, "%s:%d:%d: error: %s\n", file, syntaxError.Line+1, syntaxError.Column+1, syntaxError.Message)
+		} else {
+			fmt.Fprintf(stderr, "%s: error: %s\n", file, err)
+		}
+		return nil, false
+	}
+
+	root, included, problems := workflow.ResolveIncludes(root, file, readText)
+	settings, invalid := workflow.Settings(root)
+	problems = append(problems, invalid...)
+	for _, problem := range problems {
+		// Merged keys keep their positions in the file they come from
+		at := file
+		if from, found := workflow.IncludedFile(problem, included); found {
+			at = from.File
+		}
+		fmt.Fprintf(stderr, "%s:%d:%d: error: %s\n", at, problem.Line+1, problem.Column+1, problem.Message)
+	}
+	return settings, len(problems) == 0
+}
+
+// `--job name=id` flag, the ids of the jobs that `run_job_task` trigger by `job_name`
+func jobFlag(flags *flag.FlagSet) map[string]int64 {
+	jobIDs := map[string]int64{}
+	flags.Func("job", "`name=id` of a job that a `run_job_task` triggers by `job_name`, can be repeated", func(value string) error {
+		name, id, found := strings.Cut(value, "=")
+		parsed, err := strconv.ParseInt(id, 10, 64)
+		if !found || name == "" || err != nil {
+			return fmt.Errorf("expected `name=id`, found %q", value)
+		}
+		jobIDs[name] = parsed
+		return nil
+	})
+	return jobIDs
+}
+
+// Id of a job given by `--job`, by its name or by the path of its `.flow.yaml` from the converted file
+// A path is also looked up by the name of the job in the file it leads to
+func runJobID(file, name string, jobIDs map[string]int64) (int64, error) {
+	if id, found := jobIDs[name]; found {
+		return id, nil
+	}
+	if strings.HasSuffix(name, ".flow.yaml") {
+		path := name
+		if !filepath.IsAbs(path) && file != "-" {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		root, err := yaml.Parse(string(content))
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		if id, found := jobIDs[workflow.JobName(root, path)]; found {
+			return id, nil
+		}
+		name = workflow.JobName(root, path)
+	}
+	return 0, fmt.Errorf("no id, pass `--job %s=<id>`", name)
+}
+
+// Content of a file, `-` reads stdin
+func readInput(file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// Content of a file as text, e.g. a file included by a workflow
+func readText(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	return string(content), err
+}
+
+// Write to a file, or to stdout when no file is given
+func writeOutput(file string, stdout io.Writer, write func(io.Writer) error) error {
+	if file == "" {
+		return write(stdout)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
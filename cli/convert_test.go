@@ -0,0 +1,65 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"clean.flow.yaml":   clean,
+		"invalid.flow.yaml": "name: invalid\ntimeout_seconds: soon\n",
+	})
+	file := filepath.Join(dir, "clean.flow.yaml")
+
+	code, output, stderr := run("convert", file)
+	if code != 0 || !strings.HasPrefix(output, "{\n  \"name\": \"clean\",\n") || !strings.Contains(output, "\"access_control_list\"") {
+		t.Fatalf("Expected: a jobs/create body, Actual: %d %s %s", code, output, stderr)
+	}
+
+	code, output, _ = run("convert", "--job-id", "42", file)
+	if code != 0 || !strings.HasPrefix(output, "{\n  \"job_id\": 42,\n  \"new_settings\": {\n    \"name\": \"clean\",\n") || strings.Contains(output, "\"access_control_list\"") {
+		t.Fatalf("Expected: a jobs/reset body, Actual: %d %s", code, output)
+	}
+
+	written := filepath.Join(dir, "create.json")
+	if code, _, _ := run("convert", "-o", written, file); code != 0 {
+		t.Fatalf("Expected: 0, Actual: %d", code)
+	}
+	if content, err := os.ReadFile(written); err != nil || !strings.HasPrefix(string(content), "{\n  \"name\": \"clean\",\n") {
+		t.Fatalf("Expected: the body in %s, Actual: %s %v", written, content, err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.flow.yaml")
+	expected := invalid + ":2:18: error: "
+	if code, output, stderr := run("convert", invalid); code != 1 || output != "" || !strings.HasPrefix(stderr, expected) {
+		t.Fatalf("Expected: 1 %s..., Actual: %d %s", expected, code, stderr)
+	}
+
+	if code, _, _ := run("convert", "--job", "nameless", file); code != 2 {
+		t.Fatalf("Expected: 2, Actual: %d", code)
+	}
+}
+
+func TestConvertIncludeProblems(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"job.flow.yaml":        "include: shared/timeouts.yaml\nname: job\nmax_concurrent_runs: many\n",
+		"shared/timeouts.yaml": "# Timeouts of every job\ntimeout_seconds: soon\n",
+	})
+	file := filepath.Join(dir, "job.flow.yaml")
+
+	// Problems of an included file are reported in that file
+	code, _, stderr := run("convert", file)
+	lines := strings.Split(strings.TrimSuffix(stderr, "\n"), "\n")
+	if code != 1 || len(lines) != 2 {
+		t.Fatalf("Expected: 1 and 2 problems, Actual: %d %s", code, stderr)
+	}
+	if expected := filepath.Join(dir, "shared", "timeouts.yaml") + ":2:18: error: "; !strings.HasPrefix(lines[0], expected) {
+		t.Fatalf("Expected: %s..., Actual: %s", expected, lines[0])
+	}
+	if expected := file + ":3:22: error: "; !strings.HasPrefix(lines[1], expected) {
+		t.Fatalf("Expected: %s..., Actual: %s", expected, lines[1])
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"dbwf-ls/workflow"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var changeSigns = map[workflow.ChangeKind]string{
+	workflow.Added:    "+",
+	workflow.Removed:  "-",
+	workflow.Modified: "~",
+}
+
+// `dbwf-ls diff [flags] <before.flow.yaml> <after.flow.yaml>`
+// Compare two versions of a workflow by what they mean rather than line by line
+// Exit with 1 when they differ, like diff(1)
+func diff(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls diff [flags] <before.flow.yaml> <after.flow.yaml>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Unknown format %q, expected text or json\n", *format)
+		return 2
+	}
+
+	versions := []workflow.Object{}
+	for _, file := range flags.Args() {
+		content, err := readInput(file)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		settings, ok := loadSettings(file, content, stderr)
+		if !ok {
+			return 2
+		}
+		versions = append(versions, settings)
+	}
+	changes := workflow.Diff(versions[0], versions[1])
+
+	if *format == "json" {
+		report := []any{}
+		for _, change := range changes {
+			report = append(report, workflow.Object{
+				{Key: "change", Value: string(change.Kind)},
+				{Key: "scope", Value: change.Scope},
+				{Key: "id", Value: change.ID},
+				{Key: "path", Value: change.Path},
+				{Key: "before", Value: change.Before},
+				{Key: "after", Value: change.After},
+			})
+		}
+		if err := workflow.WriteJSON(stdout, report); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	} else {
+		for _, change := range changes {
+			fmt.Fprintln(stdout, describeChange(change))
+		}
+	}
+
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// `~ task ingest: notebook_task.notebook_path: "/a" → "/b"`
+func describeChange(change workflow.Change) string {
+	subject := []string{}
+	if change.Scope != "job" {
+		subject = append(subject, strings.ReplaceAll(change.Scope, "_", " ")+" "+change.ID)
+	}
+	if change.Path != "" {
+		subject = append(subject, change.Path)
+	}
+	line := changeSigns[change.Kind] + " " + strings.Join(subject, ": ")
+
+	switch {
+	case change.Path == "" && change.Scope == "permission":
+		permission, _ := change.After.(workflow.Object)
+		if change.Kind == workflow.Removed {
+			permission, _ = change.Before.(workflow.Object)
+		}
+		return fmt.Sprintf("%s: %v", line, permission.Get("permission_level"))
+	case change.Path == "":
+		// A whole task or cluster
+		return line
+	case change.Path == "depends_on" && change.Scope == "task":
+		return line + ": " + describeDependencies(change.Before.([]any), change.After.([]any))
+	case change.Kind == workflow.Added:
+		return line + ": " + workflow.CompactJSON(change.After)
+	case change.Kind == workflow.Removed:
+		return line + ": " + workflow.CompactJSON(change.Before)
+	}
+	return line + ": " + workflow.CompactJSON(change.Before) + " → " + workflow.CompactJSON(change.After)
+}
+
+// `+check -cleanup`, dependencies that appeared and disappeared
+func describeDependencies(before, after []any) string {
+	contains := func(list []any, key any) bool {
+		for _, item := range list {
+			if item == key {
+				return true
+			}
+		}
+		return false
+	}
+	parts := []string{}
+	for _, key := range after {
+		if !contains(before, key) {
+			parts = append(parts, fmt.Sprintf("+%s", key))
+		}
+	}
+	for _, key := range before {
+		if !contains(after, key) {
+			parts = append(parts, fmt.Sprintf("-%s", key))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,29 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"before.flow.yaml": clean,
+		"after.flow.yaml":  strings.Replace(clean, "max_concurrent_runs: 1", "max_concurrent_runs: 2", 1),
+	})
+	before, after := filepath.Join(dir, "before.flow.yaml"), filepath.Join(dir, "after.flow.yaml")
+
+	if code, output, _ := run("diff", before, before); code != 0 || output != "" {
+		t.Fatalf("Expected: 0 without output, Actual: %d %s", code, output)
+	}
+	expected := "~ max_concurrent_runs: 1 → 2\n"
+	if code, output, _ := run("diff", before, after); code != 1 || expected != output {
+		t.Fatalf("Expected: 1 %s, Actual: %d %s", expected, code, output)
+	}
+	if code, output, _ := run("diff", "--format", "json", before, after); code != 1 || !strings.Contains(output, "max_concurrent_runs") {
+		t.Fatalf("Expected: 1 and a json diff, Actual: %d %s", code, output)
+	}
+	if code, _, _ := run("diff", before); code != 2 {
+		t.Fatalf("Expected: 2, Actual: %d", code)
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"dbwf-ls/workflow"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Names of Terraform resources
+var terraformName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// `dbwf-ls export --terraform [flags] <file.flow.yaml>`
+// Write a workflow in the syntax of another deployment tool
+func export(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	terraform := flags.Bool("terraform", false, "write a `databricks_job` resource of the Databricks Terraform provider")
+	name := flags.String("name", "", "name of the resource, defaults to the name of the file")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	jobIDs := jobFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls export --terraform [flags] <file.flow.yaml|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || !*terraform {
+		flags.Usage()
+		return 2
+	}
+	file := flags.Arg(0)
+	if *name == "" {
+		*name = workflow.BundleKey(file)
+	}
+	if !terraformName.MatchString(*name) {
+		fmt.Fprintf(stderr, "invalid resource name %q, use letters, digits, `_` and `-`\n", *name)
+		return 2
+	}
+
+	content, err := readInput(file)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	settings, ok := loadSettings(file, content, stderr)
+	if !ok {
+		return 1
+	}
+	if err := workflow.ResolveJobNames(settings, func(name string) (int64, error) {
+		return runJobID(file, name, jobIDs)
+	}); err != nil {
+		fmt.Fprintf(stderr, "%s: error: %s\n", file, err)
+		return 1
+	}
+
+	if err := writeOutput(*output, stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, workflow.Terraform(settings, *name))
+		return err
+	}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"clean.flow.yaml": clean})
+	file := filepath.Join(dir, "clean.flow.yaml")
+
+	code, output, stderr := run("export", "--terraform", file)
+	if code != 0 || !strings.HasPrefix(output, "resource \"databricks_job\" \"clean\" {\n  name                = \"clean\"\n") {
+		t.Fatalf("Expected: a databricks_job resource, Actual: %d %s %s", code, output, stderr)
+	}
+	if !strings.Contains(output, "resource \"databricks_permissions\" \"clean\"") {
+		t.Fatalf("Expected: a databricks_permissions resource, Actual: %s", output)
+	}
+
+	if code, output, _ := run("export", "--terraform", "--name", "nightly", file); code != 0 || !strings.HasPrefix(output, "resource \"databricks_job\" \"nightly\" {") {
+		t.Fatalf("Expected: a resource named nightly, Actual: %d %s", code, output)
+	}
+	if code, _, _ := run("export", file); code != 2 {
+		t.Fatalf("Expected: 2, Actual: %d", code)
+	}
+}
+
+func TestExportJobNames(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"trigger.flow.yaml": "name: trigger\ntasks:\n  - task_key: run\n    run_job_task:\n      job_name: nightly\n"})
+	file := filepath.Join(dir, "trigger.flow.yaml")
+
+	code, output, stderr := run("export", "--terraform", "--job", "nightly=42", file)
+	if code != 0 || !strings.Contains(output, "job_id = 42") || strings.Contains(output, "job_name") {
+		t.Fatalf("Expected: job_id = 42, Actual: %d %s %s", code, output, stderr)
+	}
+
+	expected := file + ": error: job `nightly` of `run_job_task`: no id, pass `--job nightly=<id>`\n"
+	if code, output, stderr := run("export", "--terraform", file); code != 1 || output != "" || expected != stderr {
+		t.Fatalf("Expected: 1 %s, Actual: %d %s", expected, code, stderr)
+	}
+}
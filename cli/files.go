@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Extension of the workflow files dbwf-ls attaches to
+const flowExtension = ".flow.yaml"
+
+// Expand the command line arguments into a sorted list of files
+// An argument can be a file, a directory (searched for `*.flow.yaml`)
+// or a glob pattern, `**` matches any number of directories
+func expandPaths(args []string) ([]string, error) {
+	seen := map[string]bool{}
+	files := []string{}
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			info, err := os.Stat(arg)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(arg)
+				continue
+			}
+			err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && strings.HasSuffix(path, flowExtension) {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		matches, err := glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+
+	slices.Sort(files)
+	return files, nil
+}
+
+// `filepath.Glob` with support for `**`
+func glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	// Walk from the longest directory prefix without any wildcard
+	pattern = filepath.ToSlash(pattern)
+	segments := strings.Split(pattern, "/")
+	root := []string{}
+	for _, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			break
+		}
+		root = append(root, segment)
+	}
+	start := strings.Join(root, "/")
+	if start == "" {
+		start = "."
+	}
+
+	matches := []string{}
+	err := filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		candidate := filepath.ToSlash(path)
+		if start == "." && !strings.HasPrefix(pattern, "./") {
+			candidate = strings.TrimPrefix(candidate, "./")
+		}
+		matched, err := matchSegments(segments, strings.Split(candidate, "/"))
+		if err != nil {
+			return err
+		}
+		if matched {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// Match path segments against pattern segments, `**` matches zero or more segments
+func matchSegments(pattern, path []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(path) == 0, nil
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			matched, err := matchSegments(pattern[1:], path[i:])
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+	if len(path) == 0 {
+		return false, nil
+	}
+	matched, err := filepath.Match(pattern[0], path[0])
+	if err != nil || !matched {
+		return false, err
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,68 @@
+package cli_test
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckPaths(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.flow.yaml":             clean,
+		"jobs/b.flow.yaml":        clean,
+		"jobs/deep/c.flow.yaml":   clean,
+		"jobs/deep/d.flow.yaml":   clean,
+		"jobs/other.yaml":         clean,
+		"jobs/deep/e.flow.yml":    clean,
+		"archive/f.flow.yaml":     clean,
+		"archive/old/g.flow.yaml": clean,
+	})
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"a.flow.yaml"}, "a.flow.yaml"},
+		{[]string{"jobs"}, "jobs/b.flow.yaml jobs/deep/c.flow.yaml jobs/deep/d.flow.yaml"},
+		{[]string{"jobs/*.flow.yaml"}, "jobs/b.flow.yaml"},
+		{[]string{"jobs/*/[c]*.yaml"}, "jobs/deep/c.flow.yaml"},
+		{[]string{"**/*.flow.yaml"}, "a.flow.yaml archive/f.flow.yaml archive/old/g.flow.yaml jobs/b.flow.yaml jobs/deep/c.flow.yaml jobs/deep/d.flow.yaml"},
+		{[]string{"jobs/**/d.flow.yaml"}, "jobs/deep/d.flow.yaml"},
+		{[]string{"**/old/*.flow.yaml"}, "archive/old/g.flow.yaml"},
+		{[]string{"jobs/**/*.yml"}, "jobs/deep/e.flow.yml"},
+		{[]string{"jobs/deep/d.flow.yaml", "jobs/**/*.flow.yaml", "jobs/deep"}, "jobs/b.flow.yaml jobs/deep/c.flow.yaml jobs/deep/d.flow.yaml"},
+	}
+	for _, test := range tests {
+		args := []string{"check", "--format", "junit"}
+		for _, arg := range test.args {
+			args = append(args, filepath.Join(dir, arg))
+		}
+		code, output, stderr := run(args...)
+		if code != 0 {
+			t.Fatalf("%v, Expected: 0, Actual: %d %s", test.args, code, stderr)
+		}
+		var junit struct {
+			Cases []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"testsuite>testcase"`
+		}
+		if err := xml.Unmarshal([]byte(output), &junit); err != nil {
+			t.Fatal(err)
+		}
+		files := []string{}
+		for _, testCase := range junit.Cases {
+			relative, _ := filepath.Rel(dir, testCase.Name)
+			files = append(files, filepath.ToSlash(relative))
+		}
+		if actual := strings.Join(files, " "); test.expected != actual || !slices.IsSorted(files) {
+			t.Fatalf("%v, Expected: %s, Actual: %s", test.args, test.expected, actual)
+		}
+	}
+
+	// A glob without any match is not an error of its own, but there is nothing to check
+	if code, _, stderr := run("check", filepath.Join(dir, "**/*.json")); code != 2 || !strings.Contains(stderr, "No files to check") {
+		t.Fatalf("Expected: 2 No files to check, Actual: %d %s", code, stderr)
+	}
+}
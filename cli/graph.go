@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"dbwf-ls/workflow"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// `dbwf-ls graph [flags] <file.flow.yaml>`
+// Write the task dependency graph of a workflow as Mermaid or DOT
+func graph(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("graph", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "mermaid", "output format: mermaid or dot")
+	critical := flags.Bool("critical-path", false, "highlight the longest chain of dependent tasks")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls graph [flags] <file.flow.yaml|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if *format != "mermaid" && *format != "dot" {
+		fmt.Fprintf(stderr, "Unknown format %q, expected mermaid or dot\n", *format)
+		return 2
+	}
+
+	file := flags.Arg(0)
+	content, err := readInput(file)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	settings, ok := loadSettings(file, content, stderr)
+	if !ok {
+		return 1
+	}
+
+	graph := workflow.NewGraph(settings)
+	text := graph.Mermaid(*critical)
+	if *format == "dot" {
+		text = graph.DOT(*critical)
+	}
+	if err := writeOutput(*output, stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, text)
+		return err
+	}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	workflow := strings.Replace(clean, "tasks:\n", "tasks:\n  - task_key: report\n    depends_on:\n      - task_key: ingest\n    notebook_task:\n      notebook_path: /Shared/report\n", 1)
+	dir := writeFiles(t, map[string]string{"clean.flow.yaml": workflow})
+	file := filepath.Join(dir, "clean.flow.yaml")
+
+	tests := map[string]string{
+		"mermaid": "t1 --> t0",
+		"dot":     `"ingest" -> "report"`,
+	}
+	for format, edge := range tests {
+		code, output, stderr := run("graph", "--format", format, file)
+		if code != 0 || !strings.Contains(output, edge) {
+			t.Fatalf("%s, Expected: %s, Actual: %d %s %s", format, edge, code, output, stderr)
+		}
+	}
+
+	if code, _, _ := run("graph", "--format", "svg", file); code != 2 {
+		t.Fatalf("Expected: 2, Actual: %d", code)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// `dbwf-ls import [flags] <job.json>`
+// Write the `.flow.yaml` of a job exported from the Jobs UI or `jobs/get`
+// Fields the schema doesn't know are kept and reported as warnings
+func importJob(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "", "write to this file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dbwf-ls import [flags] <job.json|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+	content, err := readInput(file)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	root, err := workflow.Import(content)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: error: %s\n", file, err)
+		return 1
+	}
+	document := yaml.Encode(root)
+
+	// Positions only exist once the document is written, check what came out
+	if parsed, err := yaml.Parse(document); err == nil {
+		name := *output
+		if name == "" {
+			name = "<stdout>"
+		}
+		_, problems := workflow.Settings(parsed)
+		for _, problem := range problems {
+			fmt.Fprintf(stderr, "%s:%d:%d: warning: %s\n", name, problem.Line+1, problem.Column+1, problem.Message)
+		}
+	}
+
+	if err := writeOutput(*output, stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, document)
+		return err
+	}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
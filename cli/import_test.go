@@ -0,0 +1,33 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"job.json":     `{"job_id": 1, "settings": {"name": "report", "foo": 1, "tasks": [{"task_key": "a", "notebook_task": {"notebook_path": "/a"}}]}}`,
+		"invalid.json": `{"settings": `,
+	})
+
+	expected := `# Imported from Databricks job 1
+name: report
+tasks:
+  - task_key: a
+    notebook_task:
+      notebook_path: /a
+foo: 1
+`
+	code, output, stderr := run("import", filepath.Join(dir, "job.json"))
+	if code != 0 || expected != output {
+		t.Fatalf("Expected: 0 %s, Actual: %d %s", expected, code, output)
+	}
+	if warning := "<stdout>:7:1: warning: unknown field `foo` in the workflow\n"; warning != stderr {
+		t.Fatalf("Expected: %s, Actual: %s", warning, stderr)
+	}
+
+	if code, _, _ := run("import", filepath.Join(dir, "invalid.json")); code != 1 {
+		t.Fatalf("Expected: 1, Actual: %d", code)
+	}
+}
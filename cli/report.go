@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Write the reports of all checked files in a given format
+type reportWriter func(w io.Writer, reports []fileReport, threshold int) error
+
+var reportWriters = map[string]reportWriter{
+	"text":   writeText,
+	"json":   writeJSON,
+	"sarif":  writeSARIF,
+	"junit":  writeJUnit,
+	"github": writeGitHub,
+}
+
+// `file:line:col: severity: message`, lines and columns are 1-based
+func writeText(w io.Writer, reports []fileReport, _ int) error {
+	for _, report := range reports {
+		for _, d := range report.diagnostics {
+			_, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s\n",
+				report.file,
+				d.Range.Start.Line+1,
+				d.Range.Start.Character+1,
+				severityName(d.Severity),
+				d.Message,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type jsonDiagnostic struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Source    string `json:"source"`
+	Message   string `json:"message"`
+}
+
+func writeJSON(w io.Writer, reports []fileReport, _ int) error {
+	diagnostics := []jsonDiagnostic{}
+	for _, report := range reports {
+		for _, d := range report.diagnostics {
+			diagnostics = append(diagnostics, jsonDiagnostic{
+				File:      report.file,
+				Line:      d.Range.Start.Line + 1,
+				Column:    d.Range.Start.Character + 1,
+				EndLine:   d.Range.End.Line + 1,
+				EndColumn: d.Range.End.Character + 1,
+				Severity:  severityName(d.Severity),
+				Source:    d.Source,
+				Message:   d.Message,
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diagnostics)
+}
+
+// Static Analysis Results Interchange Format, the one code scanning understands
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+func writeSARIF(w io.Writer, reports []fileReport, _ int) error {
+	results := []sarifResult{}
+	for _, report := range reports {
+		for _, d := range report.diagnostics {
+			level := "note"
+			switch d.Severity {
+			case 1:
+				level = "error"
+			case 2:
+				level = "warning"
+			}
+			results = append(results, sarifResult{
+				Level:   level,
+				Message: sarifMessage{Text: d.Message},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(report.file)},
+						Region: sarifRegion{
+							StartLine:   d.Range.Start.Line + 1,
+							StartColumn: d.Range.Start.Character + 1,
+							EndLine:     d.Range.End.Line + 1,
+							EndColumn:   d.Range.End.Character + 1,
+						},
+					},
+				}},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "dbwf-ls",
+				InformationURI: "https://github.com/BaoCaiH/dbwf-ls",
+			}},
+			Results: results,
+		}},
+	})
+}
+
+// One test case per file, failing diagnostics become failures
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Failures  []junitFailure `xml:"failure,omitempty"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, reports []fileReport, threshold int) error {
+	suite := junitTestSuite{Name: "dbwf-ls", Tests: len(reports)}
+	for _, report := range reports {
+		testCase := junitTestCase{Name: report.file, ClassName: "dbwf-ls"}
+		others := []string{}
+		for _, d := range report.diagnostics {
+			location := fmt.Sprintf("%s:%d:%d", report.file, d.Range.Start.Line+1, d.Range.Start.Character+1)
+			if failing(d, threshold) {
+				testCase.Failures = append(testCase.Failures, junitFailure{
+					Message: d.Message,
+					Type:    severityName(d.Severity),
+					Text:    location,
+				})
+			} else {
+				others = append(others, fmt.Sprintf("%s: %s: %s", location, severityName(d.Severity), d.Message))
+			}
+		}
+		if len(testCase.Failures) > 0 {
+			suite.Failures++
+		}
+		testCase.SystemOut = strings.Join(others, "\n")
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// GitHub Actions workflow commands, they show up as annotations on the PR
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+func writeGitHub(w io.Writer, reports []fileReport, _ int) error {
+	for _, report := range reports {
+		for _, d := range report.diagnostics {
+			command := "notice"
+			switch d.Severity {
+			case 1:
+				command = "error"
+			case 2:
+				command = "warning"
+			}
+			_, err := fmt.Fprintf(w, "::%s file=%s,line=%d,col=%d,endLine=%d,endColumn=%d,title=dbwf-ls::%s\n",
+				command,
+				githubEscapeProperty(filepath.ToSlash(report.file)),
+				d.Range.Start.Line+1,
+				d.Range.Start.Character+1,
+				d.Range.End.Line+1,
+				d.Range.End.Character+1,
+				githubEscapeData(d.Message),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func githubEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func githubEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,195 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var monthNames = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+var weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+var ordinals = []string{"first", "second", "third", "fourth", "fifth"}
+
+// The schedule in English, e.g. `At 02:00, every day`
+func (s *Schedule) Describe() string {
+	parts := []string{s.describeTime(), s.describeDays()}
+	for _, kind := range []fieldKind{month, year} {
+		if source := s.sources[kind]; source != "*" {
+			parts = append(parts, preposition("in", s.describeField(kind, source)))
+		}
+	}
+	description := strings.Join(parts, ", ")
+	return string(unicode.ToUpper(rune(description[0]))) + description[1:]
+}
+
+// A single value of every time field is a time of the day, anything else is described field by field
+func (s *Schedule) describeTime() string {
+	single := func(kind fieldKind) (int, bool) {
+		value, found := -1, false
+		for i, allowed := range s.fields[kind] {
+			if allowed {
+				if found {
+					return 0, false
+				}
+				value, found = i, true
+			}
+		}
+		return value, found
+	}
+	h, singleHour := single(hour)
+	m, singleMinute := single(minute)
+	sec, singleSecond := single(second)
+	if singleHour && singleMinute && singleSecond {
+		if sec == 0 {
+			return fmt.Sprintf("at %02d:%02d", h, m)
+		}
+		return fmt.Sprintf("at %02d:%02d:%02d", h, m, sec)
+	}
+
+	parts := []string{}
+	if !singleSecond || sec != 0 {
+		parts = append(parts, s.describeField(second, s.sources[second]))
+	}
+	minutes := s.describeField(minute, s.sources[minute])
+	switch {
+	case s.sources[hour] != "*":
+		parts = append(parts, minutes, s.describeField(hour, s.sources[hour]))
+	case strings.HasPrefix(minutes, "every"):
+		parts = append(parts, minutes)
+	default:
+		parts = append(parts, minutes+" of every hour")
+	}
+	description := strings.Join(parts, ", ")
+	if strings.HasPrefix(description, "every") {
+		return description
+	}
+	return "at " + description
+}
+
+func (s *Schedule) describeDays() string {
+	switch {
+	case s.lastDay && s.lastDayOffset > 0:
+		return fmt.Sprintf("%d days before the last day of the month", s.lastDayOffset)
+	case s.lastDay:
+		return "on the last day of the month"
+	case s.lastWeekday:
+		return "on the last weekday of the month"
+	case s.nearestWeekday > 0:
+		return fmt.Sprintf("on the weekday nearest to day %d of the month", s.nearestWeekday)
+	case s.lastOfWeekday > 0:
+		return fmt.Sprintf("on the last %s of the month", weekdayNames[s.lastOfWeekday-1])
+	case s.nthWeekday[0] > 0:
+		return fmt.Sprintf("on the %s %s of the month", ordinals[s.nthWeekday[1]-1], weekdayNames[s.nthWeekday[0]-1])
+	case s.noDayOfWeek && s.sources[dayOfMonth] != "*":
+		return preposition("on", s.describeField(dayOfMonth, s.sources[dayOfMonth])+" of the month")
+	case s.noDayOfMonth && s.sources[dayOfWeek] != "*":
+		return preposition("on", s.describeField(dayOfWeek, s.sources[dayOfWeek]))
+	}
+	return "every day"
+}
+
+// `on Monday` or `in March`, steps read without it, e.g. `every 2 months`
+func preposition(word, description string) string {
+	if strings.HasPrefix(description, "every") {
+		return description
+	}
+	return word + " " + description
+}
+
+// One field in English, e.g. `every 15 minutes` or `hours 9 through 17`
+func (s *Schedule) describeField(kind fieldKind, source string) string {
+	spec := fieldSpecs[kind]
+	unit := strings.TrimSuffix(spec.name, "s")
+	switch kind {
+	case dayOfMonth:
+		unit = "day"
+	case dayOfWeek:
+		unit = "day of the week"
+	}
+
+	// `every 15 minutes`, but `every 2nd day of the week` since there are no `days of the week` to count
+	every := func(step string) string {
+		if kind == dayOfWeek {
+			return "every " + ordinal(step) + " " + unit
+		}
+		return "every " + step + " " + unit + "s"
+	}
+	// `starting at minute 5`, `starting on Monday`, `starting in March`
+	starting := func(low string) string {
+		switch kind {
+		case dayOfMonth:
+			return "starting on day " + s.name(kind, low)
+		case dayOfWeek:
+			return "starting on " + s.name(kind, low)
+		case month, year:
+			return "starting in " + s.name(kind, low)
+		}
+		return "starting at " + unit + " " + s.name(kind, low)
+	}
+
+	items := []string{}
+	for _, item := range strings.Split(strings.ToUpper(source), ",") {
+		rangeText, step, stepped := strings.Cut(item, "/")
+		low, high, isRange := strings.Cut(rangeText, "-")
+		switch {
+		case rangeText == "*" && stepped:
+			items = append(items, every(step))
+		case rangeText == "*":
+			items = append(items, "every "+unit)
+		case stepped && isRange:
+			items = append(items, fmt.Sprintf("%s from %s through %s", every(step), s.name(kind, low), s.name(kind, high)))
+		case stepped:
+			items = append(items, every(step)+" "+starting(low))
+		case isRange:
+			items = append(items, fmt.Sprintf("%s through %s", s.name(kind, low), s.name(kind, high)))
+		default:
+			items = append(items, s.name(kind, low))
+		}
+	}
+
+	description := strings.Join(items, ", ")
+	if kind == month || kind == dayOfWeek || kind == year || strings.HasPrefix(description, "every") {
+		return description
+	}
+	if len(items) > 1 || strings.Contains(description, "through") {
+		return unit + "s " + description
+	}
+	return unit + " " + description
+}
+
+// Name of a value, months and days of the week are spelled out
+func (s *Schedule) name(kind fieldKind, text string) string {
+	n, err := s.value(fieldSpecs[kind], text)
+	if err != nil {
+		return text
+	}
+	switch kind {
+	case month:
+		return monthNames[n-1]
+	case dayOfWeek:
+		return weekdayNames[n-1]
+	}
+	return fmt.Sprint(n)
+}
+
+// `1st`, `2nd`, `3rd`, `4th`... of a number
+func ordinal(number string) string {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return number
+	}
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+		return number + "th"
+	case n%10 == 1:
+		return number + "st"
+	case n%10 == 2:
+		return number + "nd"
+	case n%10 == 3:
+		return number + "rd"
+	}
+	return number + "th"
+}
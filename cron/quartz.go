@@ -0,0 +1,353 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Syntax error in an expression, offsets are in bytes and the end is exclusive
+type Error struct {
+	Start, End int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.Start+1, e.Message)
+}
+
+type fieldKind int
+
+const (
+	second fieldKind = iota
+	minute
+	hour
+	dayOfMonth
+	month
+	dayOfWeek
+	year
+)
+
+type fieldSpec struct {
+	name     string
+	min, max int
+	names    []string // names of the values from `min`, e.g. `JAN` is 1
+}
+
+var fieldSpecs = []fieldSpec{
+	{name: "seconds", min: 0, max: 59},
+	{name: "minutes", min: 0, max: 59},
+	{name: "hours", min: 0, max: 23},
+	{name: "day-of-month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "day-of-week", min: 1, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+	{name: "year", min: 1970, max: 2099},
+}
+
+// A parsed Quartz cron expression
+// `seconds minutes hours day-of-month month day-of-week [year]`
+type Schedule struct {
+	fields  [7][]bool // allowed values of each field, indexed by value
+	sources [7]string // text of each field, to describe it
+
+	noDayOfMonth, noDayOfWeek bool // `?`
+
+	lastDay        bool // `L` and `L-n` in day-of-month
+	lastDayOffset  int
+	nearestWeekday int  // `nW` in day-of-month
+	lastWeekday    bool // `LW` in day-of-month
+
+	lastOfWeekday int    // `nL` in day-of-week
+	nthWeekday    [2]int // `n#k` in day-of-week, weekday then rank
+}
+
+// Parse a Quartz cron expression of 6 or 7 fields
+func Parse(expression string) (*Schedule, error) {
+	type token struct {
+		text  string
+		start int
+	}
+	tokens := []token{}
+	for i := 0; i < len(expression); {
+		if expression[i] == ' ' || expression[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(expression) && expression[i] != ' ' && expression[i] != '\t' {
+			i++
+		}
+		tokens = append(tokens, token{expression[start:i], start})
+	}
+	if len(tokens) < 6 || len(tokens) > 7 {
+		end := len(expression)
+		if len(tokens) > 7 {
+			return nil, &Error{tokens[7].start, end, fmt.Sprintf("expected 6 or 7 fields, found %d", len(tokens))}
+		}
+		return nil, &Error{0, end, fmt.Sprintf("expected 6 or 7 fields (seconds minutes hours day-of-month month day-of-week [year]), found %d", len(tokens))}
+	}
+	if len(tokens) == 6 {
+		tokens = append(tokens, token{"*", len(expression)})
+	}
+
+	s := &Schedule{}
+	for kind, token := range tokens {
+		s.sources[kind] = token.text
+		if err := s.parseField(fieldKind(kind), strings.ToUpper(token.text), token.start); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case s.noDayOfMonth && s.noDayOfWeek:
+		return nil, &Error{tokens[dayOfMonth].start, tokens[dayOfWeek].start + len(tokens[dayOfWeek].text), "`?` can only be used in one of day-of-month and day-of-week"}
+	case !s.noDayOfMonth && !s.noDayOfWeek:
+		return nil, &Error{tokens[dayOfMonth].start, tokens[dayOfWeek].start + len(tokens[dayOfWeek].text), "one of day-of-month and day-of-week must be `?`"}
+	}
+	return s, nil
+}
+
+func (s *Schedule) parseField(kind fieldKind, text string, start int) error {
+	spec := fieldSpecs[kind]
+	s.fields[kind] = make([]bool, spec.max+1)
+	fail := func(offset, length int, format string, args ...any) error {
+		return &Error{start + offset, start + offset + length, fmt.Sprintf("%s: %s", spec.name, fmt.Sprintf(format, args...))}
+	}
+
+	// Characters only valid alone in their field
+	special := func() bool {
+		switch kind {
+		case dayOfMonth:
+			switch {
+			case text == "?":
+				s.noDayOfMonth = true
+			case text == "L":
+				s.lastDay = true
+			case text == "LW":
+				s.lastWeekday = true
+			case strings.HasPrefix(text, "L-"):
+				s.lastDay = true
+				s.lastDayOffset = -1
+				if offset, err := strconv.Atoi(text[2:]); err == nil && offset >= 1 && offset <= 30 {
+					s.lastDayOffset = offset
+				}
+			case strings.HasSuffix(text, "W"):
+				s.nearestWeekday = -1
+				if day, err := strconv.Atoi(text[:len(text)-1]); err == nil && day >= 1 && day <= 31 {
+					s.nearestWeekday = day
+				}
+			default:
+				return false
+			}
+			return true
+		case dayOfWeek:
+			switch {
+			case text == "?":
+				s.noDayOfWeek = true
+			case text == "L":
+				s.fields[kind][7] = true
+			case strings.HasSuffix(text, "L"):
+				s.lastOfWeekday = s.weekday(text[:len(text)-1])
+			case strings.Contains(text, "#"):
+				weekday, rank, _ := strings.Cut(text, "#")
+				s.nthWeekday = [2]int{s.weekday(weekday), -1}
+				if n, err := strconv.Atoi(rank); err == nil && n >= 1 && n <= 5 {
+					s.nthWeekday[1] = n
+				}
+			default:
+				return false
+			}
+			return true
+		}
+		return false
+	}
+	if special() {
+		switch {
+		case s.lastDayOffset < 0:
+			return fail(2, len(text)-2, "`L-n` expects n between 1 and 30")
+		case s.nearestWeekday < 0:
+			return fail(0, len(text)-1, "`nW` expects a day between 1 and 31")
+		case s.lastOfWeekday < 0:
+			return fail(0, len(text)-1, "`nL` expects a day of the week, 1-7 or SUN-SAT")
+		case s.nthWeekday[0] < 0:
+			return fail(0, strings.Index(text, "#"), "`n#k` expects a day of the week, 1-7 or SUN-SAT")
+		case s.nthWeekday[1] < 0:
+			index := strings.Index(text, "#") + 1
+			return fail(index, len(text)-index, "`n#k` expects k between 1 and 5")
+		}
+		return nil
+	}
+
+	offset := 0
+	for _, item := range strings.Split(text, ",") {
+		if item == "" {
+			return fail(offset, 1, "empty value in list")
+		}
+		if item == "?" {
+			return fail(offset, 1, "`?` is only allowed in day-of-month and day-of-week")
+		}
+		if strings.ContainsAny(item, "?#") || (kind == dayOfMonth && strings.ContainsAny(item, "LW")) || (kind == dayOfWeek && strings.HasSuffix(item, "L")) {
+			return fail(offset, len(item), "`%s` must be alone in its field", item)
+		}
+
+		rangeText, stepText, stepped := strings.Cut(item, "/")
+		low, high := spec.min, spec.max
+		if rangeText != "*" {
+			lowText, highText, isRange := strings.Cut(rangeText, "-")
+			var err error
+			if low, err = s.value(spec, lowText); err != nil {
+				return fail(offset, len(lowText), "%s", err)
+			}
+			high = low
+			if stepped && !isRange {
+				// `a/n` runs from `a` to the end of the field
+				high = spec.max
+			}
+			if isRange {
+				if high, err = s.value(spec, highText); err != nil {
+					return fail(offset+len(lowText)+1, len(highText), "%s", err)
+				}
+			}
+		}
+
+		step := 1
+		if stepped {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 || n > spec.max-spec.min+1 {
+				return fail(offset+len(rangeText)+1, len(stepText), "step `%s` must be between 1 and %d", stepText, spec.max-spec.min+1)
+			}
+			step = n
+		}
+
+		// Ranges may wrap around, e.g. `22-2` in hours or `FRI-MON` in day-of-week
+		size := spec.max - spec.min + 1
+		count := (high-low+size)%size + 1
+		for i := 0; i < count; i += step {
+			s.fields[kind][spec.min+(low-spec.min+i)%size] = true
+		}
+		offset += len(item) + 1
+	}
+	return nil
+}
+
+// Number or name of a value in a field
+func (s *Schedule) value(spec fieldSpec, text string) (int, error) {
+	for i, name := range spec.names {
+		if text == name {
+			return spec.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		if len(spec.names) > 0 {
+			return 0, fmt.Errorf("`%s` is not a number between %d and %d or one of %s-%s", text, spec.min, spec.max, spec.names[0], spec.names[len(spec.names)-1])
+		}
+		return 0, fmt.Errorf("`%s` is not a number between %d and %d", text, spec.min, spec.max)
+	}
+	if n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("`%d` is out of range, expected %d-%d", n, spec.min, spec.max)
+	}
+	return n, nil
+}
+
+// Day of the week of `nL` and `n#k`, -1 when invalid
+func (s *Schedule) weekday(text string) int {
+	n, err := s.value(fieldSpecs[dayOfWeek], text)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Whether the schedule fires on a day
+func (s *Schedule) matchesDay(date time.Time) bool {
+	y, m, d := date.Date()
+	if y > fieldSpecs[year].max || !s.fields[year][y] || !s.fields[month][m] {
+		return false
+	}
+	days := daysIn(y, m)
+	weekday := int(date.Weekday()) + 1 // Quartz counts from Sunday as 1
+
+	if s.noDayOfWeek {
+		switch {
+		case s.lastDay:
+			return d == days-s.lastDayOffset
+		case s.lastWeekday:
+			return d == nearestWeekday(y, m, days)
+		case s.nearestWeekday > 0:
+			return s.nearestWeekday <= days && d == nearestWeekday(y, m, s.nearestWeekday)
+		}
+		return s.fields[dayOfMonth][d]
+	}
+
+	switch {
+	case s.lastOfWeekday > 0:
+		return weekday == s.lastOfWeekday && d+7 > days
+	case s.nthWeekday[0] > 0:
+		return weekday == s.nthWeekday[0] && (d-1)/7+1 == s.nthWeekday[1]
+	}
+	return s.fields[dayOfWeek][weekday]
+}
+
+// Weekday closest to a day without leaving the month
+func nearestWeekday(y int, m time.Month, day int) int {
+	switch time.Date(y, m, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return 3
+		}
+		return day - 1
+	case time.Sunday:
+		if day == daysIn(y, m) {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
+
+// Whether the schedule fires at all, in any year Quartz accepts, whatever the current time
+// e.g. `0 0 0 30 FEB ?` never does
+func (s *Schedule) Fires() bool {
+	before := time.Date(fieldSpecs[year].min, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	return !s.Next(before).IsZero()
+}
+
+// First time the schedule fires strictly after a time, in the location of that time
+// The zero time when it never fires again
+func (s *Schedule) Next(after time.Time) time.Time {
+	location := after.Location()
+	y, m, d := after.Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, location); day.Year() <= fieldSpecs[year].max; day = day.AddDate(0, 0, 1) {
+		if !s.matchesDay(day) {
+			continue
+		}
+		dy, dm, dd := day.Date()
+		for h := 0; h < 24; h++ {
+			if !s.fields[hour][h] {
+				continue
+			}
+			for mi := 0; mi < 60; mi++ {
+				if !s.fields[minute][mi] {
+					continue
+				}
+				for sec := 0; sec < 60; sec++ {
+					if !s.fields[second][sec] {
+						continue
+					}
+					next := time.Date(dy, dm, dd, h, mi, sec, 0, location)
+					// Times skipped by a daylight saving change are moved by time.Date, they stay on that day
+					if next.After(after) && next.Day() == dd {
+						return next
+					}
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,121 @@
+package cron_test
+
+import (
+	"dbwf-ls/cron"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, paris)
+	tests := []struct {
+		expression, description string
+		next                    time.Time
+	}{
+		{"0 0 2 * * ?", "At 02:00, every day", time.Date(2026, 10, 20, 2, 0, 0, 0, paris)},
+		{"0 */15 * * * ?", "Every 15 minutes, every day", time.Date(2026, 10, 19, 12, 15, 0, 0, paris)},
+		{"0 30 6 L * ?", "At 06:30, on the last day of the month", time.Date(2026, 10, 31, 6, 30, 0, 0, paris)},
+		{"0 0 8 ? * 6L", "At 08:00, on the last Friday of the month", time.Date(2026, 10, 30, 8, 0, 0, 0, paris)},
+		{"0 0 8 ? * MON#1", "At 08:00, on the first Monday of the month", time.Date(2026, 11, 2, 8, 0, 0, 0, paris)},
+		{"0 0 8 15W * ?", "At 08:00, on the weekday nearest to day 15 of the month", time.Date(2026, 11, 16, 8, 0, 0, 0, paris)},
+		{"0 0 12 1 JAN ? 2027", "At 12:00, on day 1 of the month, in January, in 2027", time.Date(2027, 1, 1, 12, 0, 0, 0, paris)},
+	}
+	for _, test := range tests {
+		schedule, err := cron.Parse(test.expression)
+		if err != nil {
+			t.Fatalf("%s: %s", test.expression, err)
+		}
+		if actual := schedule.Describe(); actual != test.description {
+			t.Fatalf("Expected: %s, Actual: %s", test.description, actual)
+		}
+		if actual := schedule.Next(start); !actual.Equal(test.next) {
+			t.Fatalf("%s, expected: %s, actual: %s", test.expression, test.next, actual)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := map[string]string{
+		// Steps
+		"0 5/15 * * * ?":     "Every 15 minutes starting at minute 5, every day",
+		"0 0 9-17/2 * * ?":   "At minute 0, every 2 hours from 9 through 17, every day",
+		"0 0 8 1/5 * ?":      "At 08:00, every 5 days starting on day 1 of the month",
+		"0 0 8 ? * MON/2":    "At 08:00, every 2nd day of the week starting on Monday",
+		"0 0 8 ? * */3":      "At 08:00, every 3rd day of the week",
+		"0 0 8 1 3/3 ?":      "At 08:00, on day 1 of the month, every 3 months starting in March",
+		"0 0 8 1 1 ? 2027/2": "At 08:00, on day 1 of the month, in January, every 2 years starting in 2027",
+		"*/10 * * * * ?":     "Every 10 seconds, every minute, every day",
+		// L, W and #
+		"0 0 8 L-3 * ?":   "At 08:00, 3 days before the last day of the month",
+		"0 0 8 LW * ?":    "At 08:00, on the last weekday of the month",
+		"0 0 8 1W * ?":    "At 08:00, on the weekday nearest to day 1 of the month",
+		"0 0 8 ? * 1L":    "At 08:00, on the last Sunday of the month",
+		"0 0 8 ? * FRI#5": "At 08:00, on the fifth Friday of the month",
+		// Ranges and lists
+		"0 0 8 ? * MON-FRI":     "At 08:00, on Monday through Friday",
+		"0 0 8 ? * MON,WED":     "At 08:00, on Monday, Wednesday",
+		"0 0 8 1-10/3 * ?":      "At 08:00, every 3 days from 1 through 10 of the month",
+		"0 0 8 1,15 * ?":        "At 08:00, on days 1, 15 of the month",
+		"0 0 8 1 JAN-MAR ?":     "At 08:00, on day 1 of the month, in January through March",
+		"0 15,45 * * * ?":       "At minutes 15, 45 of every hour, every day",
+		"0 0 8 1 1 ? 2027-2030": "At 08:00, on day 1 of the month, in January, in 2027 through 2030",
+	}
+	for expression, expected := range tests {
+		schedule, err := cron.Parse(expression)
+		if err != nil {
+			t.Fatalf("%s: %s", expression, err)
+		}
+		if actual := schedule.Describe(); expected != actual {
+			t.Fatalf("%s, Expected: %s, Actual: %s", expression, expected, actual)
+		}
+	}
+}
+
+func TestFires(t *testing.T) {
+	tests := map[string]bool{
+		"0 0 2 * * ?":          true,
+		"0 0 0 1 1 ? 1970":     true,
+		"0 0 0 1 1 ? 2020":     true,
+		"0 0 0 30 FEB ?":       false,
+		"0 0 0 31 APR,JUN ?":   false,
+		"0 0 0 ? FEB 2#5 2026": false,
+	}
+	for expression, expected := range tests {
+		schedule, err := cron.Parse(expression)
+		if err != nil {
+			t.Fatalf("%s: %s", expression, err)
+		}
+		if actual := schedule.Fires(); expected != actual {
+			t.Fatalf("%s, Expected: %t, Actual: %t", expression, expected, actual)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		start, end int
+	}{
+		{"0 0 25 * * ?", 4, 6},
+		{"0 0 1 * *", 0, 9},
+		{"0 0 1 * * *", 6, 11},
+		{"0 0 1 ? * MON-FRX", 14, 17},
+		{"0 0/0 1 ? * *", 4, 5},
+		{"0 0 1 L-40 * ?", 8, 10},
+	}
+	for _, test := range tests {
+		_, err := cron.Parse(test.expression)
+		var cronError *cron.Error
+		if !errors.As(err, &cronError) {
+			t.Fatalf("%s, expected an error, got: %v", test.expression, err)
+		}
+		if cronError.Start != test.start || cronError.End != test.end {
+			t.Fatalf("%s, expected: %d-%d, actual: %d-%d %s", test.expression, test.start, test.end, cronError.Start, cronError.End, cronError.Message)
+		}
+	}
+}
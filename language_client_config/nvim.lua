@@ -7,6 +7,16 @@ Let's say you save this file as `./custom/plugins/dbwf-ls.lua`:
 	
 	require("custom.plugins.dbwf-ls")
 ]]
+-- Code lenses list locations through the command VS Code shows references with
+vim.lsp.commands["editor.action.showReferences"] = function(command)
+	local locations = command.arguments[3]
+	if not locations or #locations == 0 then
+		return
+	end
+	vim.fn.setqflist({}, " ", { title = command.title, items = vim.lsp.util.locations_to_items(locations, "utf-16") })
+	vim.cmd "copen"
+end
+
 -- BufRead* alone will not recognise newly created file from netrw buf
 vim.api.nvim_create_autocmd({ "BufRead", "BufNewfile" }, {
 	pattern = "*.flow.yaml",
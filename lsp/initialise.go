@@ -6,7 +6,36 @@ type InitialiseRequest struct {
 }
 
 type InitialiseRequestParams struct {
-	ClientInfo *ClientInfo `json:"clientInfo"`
+	ClientInfo            *ClientInfo           `json:"clientInfo"`
+	Capabilities          ClientCapabilities    `json:"capabilities"`
+	InitializationOptions InitializationOptions `json:"initializationOptions"`
+	RootURI               string                `json:"rootUri"` // deprecated in favour of `workspaceFolders`
+	WorkspaceFolders      []WorkspaceFolder     `json:"workspaceFolders"`
+}
+
+// Capabilities of the client, only the ones the server looks at
+type ClientCapabilities struct {
+	Workspace WorkspaceClientCapabilities `json:"workspace"`
+}
+
+type WorkspaceClientCapabilities struct {
+	DidChangeWatchedFiles DynamicRegistrationCapability `json:"didChangeWatchedFiles"`
+}
+
+type DynamicRegistrationCapability struct {
+	DynamicRegistration bool `json:"dynamicRegistration"`
+}
+
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// Settings of dbwf-ls, sent by the client when initialising
+type InitializationOptions struct {
+	TaskOrder      string            `json:"taskOrder,omitempty"`      // `alphabetical` or `topological` to sort tasks when formatting
+	CheckFiles     bool              `json:"checkFiles,omitempty"`     // warn about local paths that don't exist
+	WorkspacePaths map[string]string `json:"workspacePaths,omitempty"` // local folders of workspace prefixes, e.g. `/Workspace/Repos/me/project`
 }
 
 type ClientInfo struct {
@@ -25,19 +54,32 @@ type InitialiseResult struct {
 }
 
 type ServerCapabilities struct {
-	TextDocumentSync           int            `json:"textDocumentSync"`
-	HoverProvider              bool           `json:"hoverProvider"`
-	DefinitionProvider         bool           `json:"definitionProvider"`
-	CodeActionProvider         bool           `json:"codeActionProvider"`
-	CompletionProvider         map[string]any `json:"completionProvider"`
-	DocumentFormattingProvider bool           `json:"documentFormattingProvider"`
+	TextDocumentSync                 int                             `json:"textDocumentSync"`
+	HoverProvider                    bool                            `json:"hoverProvider"`
+	DefinitionProvider               bool                            `json:"definitionProvider"`
+	CodeActionProvider               bool                            `json:"codeActionProvider"`
+	CompletionProvider               map[string]any                  `json:"completionProvider"`
+	DocumentFormattingProvider       bool                            `json:"documentFormattingProvider"`
+	DocumentRangeFormattingProvider  bool                            `json:"documentRangeFormattingProvider"`
+	DocumentOnTypeFormattingProvider DocumentOnTypeFormattingOptions `json:"documentOnTypeFormattingProvider"`
+	FoldingRangeProvider             bool                            `json:"foldingRangeProvider"`
+	SelectionRangeProvider           bool                            `json:"selectionRangeProvider"`
+	SemanticTokensProvider           SemanticTokensOptions           `json:"semanticTokensProvider"`
+	InlayHintProvider                bool                            `json:"inlayHintProvider"`
+	ReferencesProvider               bool                            `json:"referencesProvider"`
+	DocumentHighlightProvider        bool                            `json:"documentHighlightProvider"`
+	DocumentLinkProvider             DocumentLinkOptions             `json:"documentLinkProvider"`
+	CodeLensProvider                 CodeLensOptions                 `json:"codeLensProvider"`
+	ExecuteCommandProvider           ExecuteCommandOptions           `json:"executeCommandProvider"`
+	WorkspaceSymbolProvider          bool                            `json:"workspaceSymbolProvider"`
+	RenameProvider                   RenameOptions                   `json:"renameProvider"`
 }
 type ServerInfo struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
 }
 
-func NewInitialiseResponse(id int) InitialiseResponse {
+func NewInitialiseResponse(id int, commands []string, legend SemanticTokensLegend) InitialiseResponse {
 	return InitialiseResponse{
 		Response: Response{
 			RPC: "2.0",
@@ -46,12 +88,35 @@ func NewInitialiseResponse(id int) InitialiseResponse {
 		Result: InitialiseResult{
 			Capabitities: ServerCapabilities{
 				// TODO: Change to incremental
-				TextDocumentSync:           1,
-				HoverProvider:              true,
-				DefinitionProvider:         true,
-				CodeActionProvider:         true,
-				CompletionProvider:         map[string]any{},
-				DocumentFormattingProvider: true,
+				TextDocumentSync:                1,
+				HoverProvider:                   true,
+				DefinitionProvider:              true,
+				CodeActionProvider:              true,
+				CompletionProvider:              map[string]any{},
+				DocumentFormattingProvider:      true,
+				DocumentRangeFormattingProvider: true,
+				DocumentOnTypeFormattingProvider: DocumentOnTypeFormattingOptions{
+					FirstTriggerCharacter: "\n",
+				},
+				FoldingRangeProvider:   true,
+				SelectionRangeProvider: true,
+				SemanticTokensProvider: SemanticTokensOptions{
+					Legend: legend,
+					Range:  true,
+					Full:   true,
+				},
+				InlayHintProvider:         true,
+				ReferencesProvider:        true,
+				DocumentHighlightProvider: true,
+				DocumentLinkProvider:      DocumentLinkOptions{},
+				CodeLensProvider:          CodeLensOptions{},
+				ExecuteCommandProvider: ExecuteCommandOptions{
+					Commands: commands,
+				},
+				WorkspaceSymbolProvider: true,
+				RenameProvider: RenameOptions{
+					PrepareProvider: true,
+				},
 			},
 			ServerInfo: ServerInfo{
 				Name:    "dbwf-ls",
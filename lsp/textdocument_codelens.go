@@ -0,0 +1,24 @@
+package lsp
+
+type CodeLensRequest struct {
+	Request
+	Params CodeLensParams `json:"params"`
+}
+
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeLensResponse struct {
+	Response
+	Result []CodeLens `json:"result"`
+}
+
+type CodeLensOptions struct {
+	ResolveProvider bool `json:"resolveProvider"`
+}
+
+type CodeLens struct {
+	Range   Range    `json:"range"`
+	Command *Command `json:"command,omitempty"`
+}
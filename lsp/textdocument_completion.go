@@ -25,6 +25,7 @@ type CompletionItem struct {
 	Detail        string        `json:"detail"`
 	Documentation MarkupContent `json:"documentation"`
 	InsertText    string        `json:"insertText"`
+	TextEdit      *TextEdit     `json:"textEdit,omitempty"`
 }
 
 type MarkupContent struct {
@@ -16,4 +16,10 @@ type Diagnostics struct {
 	Severity int    `json:"severity"`
 	Source   string `json:"source"`
 	Message  string `json:"message"`
+	Tags     []int  `json:"tags,omitempty"`
 }
+
+const (
+	UnnecessaryTag = 1
+	DeprecatedTag  = 2
+)
@@ -0,0 +1,26 @@
+package lsp
+
+type DocumentHighlightRequest struct {
+	Request
+	Params DocumentHighlightParams `json:"params"`
+}
+
+type DocumentHighlightParams struct {
+	TextDocumentPositionParams
+}
+
+type DocumentHighlightResponse struct {
+	Response
+	Result []DocumentHighlight `json:"result"`
+}
+
+const (
+	TextHighlight  = 1
+	ReadHighlight  = 2
+	WriteHighlight = 3
+)
+
+type DocumentHighlight struct {
+	Range Range `json:"range"`
+	Kind  int   `json:"kind"`
+}
@@ -0,0 +1,23 @@
+package lsp
+
+type FoldingRangeRequest struct {
+	Request
+	Params FoldingRangeParams `json:"params"`
+}
+
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type FoldingRangeResponse struct {
+	Response
+	Result []FoldingRange `json:"result"`
+}
+
+// Lines are 0-based and both included
+type FoldingRange struct {
+	StartLine     int    `json:"startLine"`
+	EndLine       int    `json:"endLine"`
+	Kind          string `json:"kind,omitempty"` // `comment`, `imports` or `region`
+	CollapsedText string `json:"collapsedText,omitempty"`
+}
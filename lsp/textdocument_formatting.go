@@ -22,3 +22,30 @@ type DocumentFormattingResponse struct {
 	Response
 	Result []TextEdit `json:"result"`
 }
+
+type DocumentRangeFormattingRequest struct {
+	Request
+	Params DocumentRangeFormattingParams `json:"params"`
+}
+
+type DocumentRangeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+type DocumentOnTypeFormattingRequest struct {
+	Request
+	Params DocumentOnTypeFormattingParams `json:"params"`
+}
+
+type DocumentOnTypeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	Ch           string                 `json:"ch"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+type DocumentOnTypeFormattingOptions struct {
+	FirstTriggerCharacter string `json:"firstTriggerCharacter"`
+}
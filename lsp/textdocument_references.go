@@ -0,0 +1,20 @@
+package lsp
+
+type ReferencesRequest struct {
+	Request
+	Params ReferencesParams `json:"params"`
+}
+
+type ReferencesParams struct {
+	TextDocumentPositionParams
+	Context ReferenceContext `json:"context"`
+}
+
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+}
+
+type ReferencesResponse struct {
+	Response
+	Result []Location `json:"result"`
+}
@@ -0,0 +1,30 @@
+package lsp
+
+type RenameRequest struct {
+	Request
+	Params RenameParams `json:"params"`
+}
+
+type RenameParams struct {
+	TextDocumentPositionParams
+	NewName string `json:"newName"`
+}
+
+type RenameResponse struct {
+	Response
+	Result WorkspaceEdit `json:"result"`
+}
+
+type PrepareRenameRequest struct {
+	Request
+	Params TextDocumentPositionParams `json:"params"`
+}
+
+type PrepareRenameResponse struct {
+	Response
+	Result *Range `json:"result"` // null when nothing can be renamed at the position
+}
+
+type RenameOptions struct {
+	PrepareProvider bool `json:"prepareProvider"`
+}
@@ -0,0 +1,22 @@
+package lsp
+
+type SelectionRangeRequest struct {
+	Request
+	Params SelectionRangeParams `json:"params"`
+}
+
+type SelectionRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Positions    []Position             `json:"positions"`
+}
+
+type SelectionRangeResponse struct {
+	Response
+	Result []SelectionRange `json:"result"`
+}
+
+// A range and the ranges containing it, up to the whole document
+type SelectionRange struct {
+	Range  Range           `json:"range"`
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
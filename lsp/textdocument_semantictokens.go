@@ -0,0 +1,41 @@
+package lsp
+
+type SemanticTokensRequest struct {
+	Request
+	Params SemanticTokensParams `json:"params"`
+}
+
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SemanticTokensRangeRequest struct {
+	Request
+	Params SemanticTokensRangeParams `json:"params"`
+}
+
+type SemanticTokensRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type SemanticTokensResponse struct {
+	Response
+	Result SemanticTokens `json:"result"`
+}
+
+// Tokens as groups of 5 integers: line and start relative to the previous token, length, type and modifiers
+type SemanticTokens struct {
+	Data []int `json:"data"`
+}
+
+type SemanticTokensLegend struct {
+	TokenTypes     []string `json:"tokenTypes"`
+	TokenModifiers []string `json:"tokenModifiers"`
+}
+
+type SemanticTokensOptions struct {
+	Legend SemanticTokensLegend `json:"legend"`
+	Range  bool                 `json:"range"`
+	Full   bool                 `json:"full"`
+}
@@ -0,0 +1,33 @@
+package lsp
+
+import "encoding/json"
+
+type ExecuteCommandRequest struct {
+	Request
+	Params ExecuteCommandParams `json:"params"`
+}
+
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
+type ExecuteCommandResponse struct {
+	Response
+	Result any `json:"result"`
+}
+
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
+
+// Request sent by the server to the client, to change files of the workspace
+type ApplyWorkspaceEditRequest struct {
+	Request
+	Params ApplyWorkspaceEditParams `json:"params"`
+}
+
+type ApplyWorkspaceEditParams struct {
+	Label string        `json:"label,omitempty"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
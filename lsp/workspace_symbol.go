@@ -0,0 +1,22 @@
+package lsp
+
+type WorkspaceSymbolRequest struct {
+	Request
+	Params WorkspaceSymbolParams `json:"params"`
+}
+
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+type WorkspaceSymbolResponse struct {
+	Response
+	Result []SymbolInformation `json:"result"`
+}
+
+type SymbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"` // 2: Module, 5: Class, 23: Struct...
+	Location      Location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"dbwf-ls/analysis"
+	"dbwf-ls/cli"
 	"dbwf-ls/error"
 	"dbwf-ls/jsonrpc"
 	"dbwf-ls/lsp"
@@ -15,6 +16,11 @@ import (
 
 // DBWF-LS
 func main() {
+	// Subcommands, e.g. `dbwf-ls check`, run without the language server
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		log.Fatal("[dbwf-ls] HOME NOT SET???")
@@ -36,10 +42,16 @@ func main() {
 			logger.Printf("Some errors occurred: %s", err)
 			continue
 		}
-		handleMessage(logger, writer, state, method, contents)
+		handleMessage(logger, writer, &state, method, contents)
 	}
 }
 
+// Id of the last request sent by the server to the client
+var serverRequests = 0
+
+// Whether the client lets the server register a watcher of the workflows on disk
+var watchFiles = false
+
 // Write a given response to the client
 func writeResponse(writer io.Writer, msg any) {
 	reply, _ := jsonrpc.EncodeMessage(msg)
@@ -47,7 +59,7 @@ func writeResponse(writer io.Writer, msg any) {
 }
 
 // Handle incoming messages
-func handleMessage(logger *log.Logger, writer io.Writer, state analysis.State, method string, contents []byte) {
+func handleMessage(logger *log.Logger, writer io.Writer, state *analysis.State, method string, contents []byte) {
 	logger.Printf("Received msg with method: %s", method)
 
 	switch method {
@@ -57,9 +69,45 @@ func handleMessage(logger *log.Logger, writer io.Writer, state analysis.State, m
 			logger.Printf("WHY IS IT AN ACORN? %s", err)
 		}
 		logger.Printf("Attached to %s client version %s", request.Params.ClientInfo.Name, request.Params.ClientInfo.Version)
-		msg := lsp.NewInitialiseResponse(request.ID)
+		state.Options = request.Params.InitializationOptions
+		watchFiles = request.Params.Capabilities.Workspace.DidChangeWatchedFiles.DynamicRegistration
+		msg := lsp.NewInitialiseResponse(request.ID, analysis.CommandNames(), analysis.SemanticTokensLegend())
 		writeResponse(writer, msg)
 		logger.Print("Reply sent")
+
+		folders := []string{}
+		for _, folder := range request.Params.WorkspaceFolders {
+			folders = append(folders, folder.URI)
+		}
+		if len(folders) == 0 && request.Params.RootURI != "" {
+			folders = append(folders, request.Params.RootURI)
+		}
+		// Big workspaces take a while, requests are answered meanwhile
+		go state.IndexWorkspace(folders, logger)
+	case "initialized":
+		// Changes of the workflows on disk keep the index of the workspace fresh
+		if !watchFiles {
+			logger.Print("Client can't register a file watcher, the index is only read on startup")
+			return
+		}
+		serverRequests++
+		writeResponse(writer, lsp.RegistrationRequest{
+			Request: lsp.Request{
+				RPC:    "2.0",
+				ID:     serverRequests,
+				Method: "client/registerCapability",
+			},
+			Params: lsp.RegistrationParams{
+				Registrations: []lsp.Registration{{
+					ID:     "dbwf-ls/watchWorkflows",
+					Method: "workspace/didChangeWatchedFiles",
+					RegisterOptions: lsp.DidChangeWatchedFilesRegistrationOptions{
+						Watchers: []lsp.FileSystemWatcher{{GlobPattern: "**/*.flow.yaml"}},
+					},
+				}},
+			},
+		})
+		logger.Print("Registration request sent")
 	case "textDocument/didOpen":
 		var noti lsp.DidOpenTextNotification
 		if err := json.Unmarshal(contents, &noti); err != nil {
@@ -134,7 +182,7 @@ func handleMessage(logger *log.Logger, writer io.Writer, state analysis.State, m
 		}
 
 		// CodeAction response
-		response, err := state.CodeAction(request.ID, request.Params.TextDocument.URI, logger)
+		response, err := state.CodeAction(request.ID, request.Params.TextDocument.URI, request.Params.Range, logger)
 		if err != nil {
 			writeResponse(writer, lsp.ErrorResponse{
 				Response: lsp.Response{
@@ -175,6 +223,176 @@ func handleMessage(logger *log.Logger, writer io.Writer, state analysis.State, m
 			writeResponse(writer, response)
 		}
 		logger.Print("Formatting response sent")
+	case "textDocument/rangeFormatting":
+		var request lsp.DocumentRangeFormattingRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/rangeFormatting %s", err)
+			return
+		}
+
+		// Range formatting response
+		response, err := state.DocumentRangeFormatting(request.ID, request.Params.TextDocument.URI, request.Params.Range, logger)
+
+		if err != nil {
+			writeResponse(writer, lsp.ErrorResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Error: error.ResponseError{
+					Code:    error.InternalError,
+					Message: fmt.Sprintf("Internal error: %s", err),
+				},
+			})
+		} else {
+			writeResponse(writer, response)
+		}
+		logger.Print("Range formatting response sent")
+	case "textDocument/onTypeFormatting":
+		var request lsp.DocumentOnTypeFormattingRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/onTypeFormatting %s", err)
+			return
+		}
+
+		// On type formatting response
+		response := state.DocumentOnTypeFormatting(request.ID, request.Params.TextDocument.URI, request.Params.Position, request.Params.Ch)
+		writeResponse(writer, response)
+		logger.Print("On type formatting response sent")
+	case "textDocument/foldingRange":
+		var request lsp.FoldingRangeRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/foldingRange %s", err)
+			return
+		}
+
+		// Folding range response
+		response := state.FoldingRange(request.ID, request.Params.TextDocument.URI)
+		writeResponse(writer, response)
+		logger.Print("Folding range response sent")
+	case "textDocument/selectionRange":
+		var request lsp.SelectionRangeRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/selectionRange %s", err)
+			return
+		}
+
+		// Selection range response
+		response := state.SelectionRange(request.ID, request.Params.TextDocument.URI, request.Params.Positions)
+		writeResponse(writer, response)
+		logger.Print("Selection range response sent")
+	case "textDocument/semanticTokens/full":
+		var request lsp.SemanticTokensRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/semanticTokens/full %s", err)
+			return
+		}
+
+		// Semantic tokens response
+		response := state.SemanticTokens(request.ID, request.Params.TextDocument.URI, nil)
+		writeResponse(writer, response)
+		logger.Print("Semantic tokens response sent")
+	case "textDocument/semanticTokens/range":
+		var request lsp.SemanticTokensRangeRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/semanticTokens/range %s", err)
+			return
+		}
+
+		// Semantic tokens response
+		response := state.SemanticTokens(request.ID, request.Params.TextDocument.URI, &request.Params.Range)
+		writeResponse(writer, response)
+		logger.Print("Semantic tokens response sent")
+	case "textDocument/inlayHint":
+		var request lsp.InlayHintRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/inlayHint %s", err)
+			return
+		}
+
+		// Inlay hint response
+		response := state.InlayHint(request.ID, request.Params.TextDocument.URI, request.Params.Range)
+		writeResponse(writer, response)
+		logger.Print("Inlay hint response sent")
+	case "textDocument/references":
+		var request lsp.ReferencesRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/references %s", err)
+			return
+		}
+
+		// References response
+		response := state.References(request.ID, request.Params.TextDocument.URI, request.Params.Position, request.Params.Context.IncludeDeclaration)
+		writeResponse(writer, response)
+		logger.Print("References response sent")
+	case "textDocument/prepareRename":
+		var request lsp.PrepareRenameRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/prepareRename %s", err)
+			return
+		}
+
+		// Prepare rename response
+		response := state.PrepareRename(request.ID, request.Params.TextDocument.URI, request.Params.Position)
+		writeResponse(writer, response)
+		logger.Print("Prepare rename response sent")
+	case "textDocument/rename":
+		var request lsp.RenameRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/rename %s", err)
+			return
+		}
+
+		// Rename response
+		response, err := state.Rename(request.ID, request.Params.TextDocument.URI, request.Params.Position, request.Params.NewName)
+		if err != nil {
+			writeResponse(writer, lsp.ErrorResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Error: error.ResponseError{
+					Code:    error.InvalidParams,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		writeResponse(writer, response)
+		logger.Print("Rename response sent")
+	case "textDocument/codeLens":
+		var request lsp.CodeLensRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/codeLens %s", err)
+			return
+		}
+
+		// Code lens response
+		response := state.CodeLens(request.ID, request.Params.TextDocument.URI)
+		writeResponse(writer, response)
+		logger.Print("Code lens response sent")
+	case "textDocument/documentHighlight":
+		var request lsp.DocumentHighlightRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/documentHighlight %s", err)
+			return
+		}
+
+		// Document highlight response
+		response := state.DocumentHighlight(request.ID, request.Params.TextDocument.URI, request.Params.Position)
+		writeResponse(writer, response)
+		logger.Print("Document highlight response sent")
+	case "textDocument/documentLink":
+		var request lsp.DocumentLinkRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("textDocument/documentLink %s", err)
+			return
+		}
+
+		// Document link response
+		response := state.DocumentLink(request.ID, request.Params.TextDocument.URI)
+		writeResponse(writer, response)
+		logger.Print("Document link response sent")
 	case "textDocument/completion":
 		var request lsp.CompletionRequest
 		if err := json.Unmarshal(contents, &request); err != nil {
@@ -200,7 +418,68 @@ func handleMessage(logger *log.Logger, writer io.Writer, state analysis.State, m
 			writeResponse(writer, response)
 		}
 		logger.Print("Completion response sent")
+	case "workspace/executeCommand":
+		var request lsp.ExecuteCommandRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("workspace/executeCommand %s", err)
+			return
+		}
+
+		// Execute command response
+		response, edit, err := state.ExecuteCommand(request.ID, request.Params, logger)
+
+		if err != nil {
+			writeResponse(writer, lsp.ErrorResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Error: error.ResponseError{
+					Code:    error.InternalError,
+					Message: fmt.Sprintf("Internal error: %s", err),
+				},
+			})
+			return
+		}
+		writeResponse(writer, response)
+		logger.Print("Execute command response sent")
+
+		if edit != nil {
+			// The client answers with the same id, nothing waits for it
+			serverRequests++
+			writeResponse(writer, lsp.ApplyWorkspaceEditRequest{
+				Request: lsp.Request{
+					RPC:    "2.0",
+					ID:     serverRequests,
+					Method: "workspace/applyEdit",
+				},
+				Params: lsp.ApplyWorkspaceEditParams{
+					Label: "Import job",
+					Edit:  *edit,
+				},
+			})
+			logger.Print("Apply edit request sent")
+		}
+	case "workspace/didChangeWatchedFiles":
+		var noti lsp.DidChangeWatchedFilesNotification
+		if err := json.Unmarshal(contents, &noti); err != nil {
+			logger.Printf("workspace/didChangeWatchedFiles %s", err)
+			return
+		}
+		state.ChangeWatchedFiles(noti.Params.Changes, logger)
+		logger.Printf("Indexed %d changed files", len(noti.Params.Changes))
+	case "workspace/symbol":
+		var request lsp.WorkspaceSymbolRequest
+		if err := json.Unmarshal(contents, &request); err != nil {
+			logger.Printf("workspace/symbol %s", err)
+			return
+		}
+
+		// Workspace symbol response
+		response := state.WorkspaceSymbol(request.ID, request.Params.Query)
 
+		writeResponse(writer, response)
+		logger.Print("Workspace symbol response sent")
 	}
 }
 
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The binary under test runs `main` with the arguments of `DBWF_LS_ARGS`
+func TestMain(m *testing.M) {
+	if args, found := os.LookupEnv("DBWF_LS_ARGS"); found {
+		os.Args = append([]string{"dbwf-ls"}, strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestDispatch(t *testing.T) {
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, ".config", "dbwf-ls"), 0o755)
+	tests := []struct {
+		args  string
+		usage bool
+	}{
+		{"--help", true},
+		{"-h", true},
+		{"help", true},
+		{"--stdio", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		// The language server reads stdin until it is closed, here right away
+		command := exec.Command(os.Args[0])
+		command.Env = append(os.Environ(), "DBWF_LS_ARGS="+test.args, "HOME="+home)
+		output, err := command.Output()
+		if err != nil {
+			t.Fatalf("%q, Expected: exit code 0, Actual: %s", test.args, err)
+		}
+		if usage := strings.HasPrefix(string(output), "Usage: dbwf-ls"); test.usage != usage {
+			t.Fatalf("%q, Expected: usage %t, Actual: %s", test.args, test.usage, output)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package workflow
+
+import (
+	"dbwf-ls/yaml"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Fields of a job that the bundle manages itself, they are dropped from the resource
+var bundleManaged = []string{"deployment", "format"}
+
+// Fields named differently in a bundle, `.flow.yaml` name to bundle name
+var bundleRenames = map[string]string{
+	"access_control_list": "permissions",
+	"permission_level":    "level",
+}
+
+// Turn a `.flow.yaml` into a bundle resource file, the job lives under `resources.jobs.<key>`
+// Comments are kept, comments above the first key stay at the top of the file
+// The tree of the workflow is reused, it shouldn't be used afterwards
+func Bundle(root *yaml.Node, key string) *yaml.Node {
+	job := &yaml.Node{Kind: yaml.MappingNode}
+	header := ""
+	if root.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			name, value := root.Content[i], root.Content[i+1]
+			if slices.Contains(bundleManaged, name.Value) {
+				continue
+			}
+			if i == 0 {
+				header, name.HeadComment = name.HeadComment, ""
+			}
+			apiNames(value, Job.Field(name.Value))
+			if name.Value == "access_control_list" {
+				name = renameKey(name, "permissions")
+				for _, permission := range value.Content {
+					renameKeys(permission, bundleRenames)
+				}
+			}
+			job.Content = append(job.Content, name, value)
+		}
+		job.FootComment = root.FootComment
+	}
+
+	return mapping(header,
+		"resources", mapping("",
+			"jobs", mapping("",
+				key, job,
+			),
+		),
+	)
+}
+
+// Turn the jobs of a bundle resource file back into `.flow.yaml` documents, by resource key
+// Only the top-level `resources.jobs` are read, targets and includes are left out
+func FromBundle(root *yaml.Node) (map[string]*yaml.Node, []Problem) {
+	jobs := root.Get("resources").Get("jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil, []Problem{problemAt(root, "no job found under `resources.jobs`")}
+	}
+
+	reverse := map[string]string{}
+	for name, renamed := range bundleRenames {
+		reverse[renamed] = name
+	}
+	workflows := map[string]*yaml.Node{}
+	problems := []Problem{}
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		key, job := jobs.Content[i], jobs.Content[i+1]
+		if job.Kind != yaml.MappingNode {
+			problems = append(problems, problemAt(job, "job `%s` must be a mapping, found %s", key.Value, job.Describe()))
+			continue
+		}
+		for j := 0; j+1 < len(job.Content); j += 2 {
+			if job.Content[j].Value != "permissions" {
+				continue
+			}
+			job.Content[j] = renameKey(job.Content[j], "access_control_list")
+			for _, permission := range job.Content[j+1].Content {
+				renameKeys(permission, reverse)
+			}
+		}
+		if len(job.Content) > 0 && key.HeadComment != "" {
+			job.Content[0].HeadComment = strings.TrimPrefix(key.HeadComment+"\n"+job.Content[0].HeadComment, "\n")
+		}
+		workflows[key.Value] = job
+	}
+	return workflows, problems
+}
+
+// Key of the resource of a workflow, from the name of its file, e.g. `nightly-report.flow.yaml` is `nightly_report`
+func BundleKey(file string) string {
+	name := file[strings.LastIndexAny(file, `/\`)+1:]
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".yaml"), ".flow")
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, name)
+	if key == "" {
+		return "job"
+	}
+	return key
+}
+
+// Mapping of keys and values, with a comment above its first key
+func mapping(head string, pairs ...any) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(pairs[i])}
+		node.Content = append(node.Content, key, pairs[i+1].(*yaml.Node))
+	}
+	if len(node.Content) > 0 {
+		node.Content[0].HeadComment = head
+	}
+	return node
+}
+
+// Rename the keys that dbwf-ls accepts under another spelling to their name in the API
+// e.g. `service_principle_name`, as the Jobs API converter does
+func apiNames(node *yaml.Node, field *Field) {
+	if field == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := field.Values
+			if field.Type == ObjectType {
+				child = field.Field(node.Content[i].Value)
+			}
+			if child != nil && child.Alias != "" {
+				node.Content[i] = renameKey(node.Content[i], child.APIName())
+			}
+			apiNames(node.Content[i+1], child)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			apiNames(item, field.Items)
+		}
+	}
+}
+
+func renameKey(key *yaml.Node, name string) *yaml.Node {
+	renamed := *key
+	renamed.Value = name
+	renamed.Style = yaml.PlainStyle
+	return &renamed
+}
+
+func renameKeys(node *yaml.Node, names map[string]string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if name, found := names[node.Content[i].Value]; found {
+			node.Content[i] = renameKey(node.Content[i], name)
+		}
+	}
+}
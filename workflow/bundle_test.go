@@ -0,0 +1,79 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	document := `# Nightly report
+name: "Nightly"
+tasks:
+  - task_key: "report" # the only one
+    description: "Weekly report"
+access_control_list:
+  - group_name: "users"
+    permission_level: CAN_VIEW
+`
+	expected := `# Nightly report
+resources:
+  jobs:
+    nightly_report:
+      name: "Nightly"
+      tasks:
+        - task_key: "report" # the only one
+          description: "Weekly report"
+      permissions:
+        - group_name: "users"
+          level: CAN_VIEW
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := yaml.Encode(workflow.Bundle(root, workflow.BundleKey("jobs/nightly-report.flow.yaml")))
+	if expected != bundle {
+		t.Fatalf("Expected: %s, Actual: %s", expected, bundle)
+	}
+
+	root, err = yaml.Parse(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflows, problems := workflow.FromBundle(root)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	expected = document[len("# Nightly report\n"):]
+	if actual := yaml.Encode(workflows["nightly_report"]); expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestBundleAPINames(t *testing.T) {
+	document := `name: "Nightly"
+run_as:
+  service_principle_name: "sp"
+access_control_list:
+  - service_principle_name: "sp"
+    permission_level: CAN_MANAGE
+`
+	expected := `resources:
+  jobs:
+    nightly:
+      name: "Nightly"
+      run_as:
+        service_principal_name: "sp"
+      permissions:
+        - service_principal_name: "sp"
+          level: CAN_MANAGE
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := yaml.Encode(workflow.Bundle(root, "nightly")); expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
@@ -0,0 +1,282 @@
+package workflow
+
+import (
+	"dbwf-ls/yaml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Something in the document that doesn't fit the schema
+// Lines and columns are 0-based, the end is exclusive
+type Problem struct {
+	Line, Column       int
+	EndLine, EndColumn int
+	Message            string
+
+	node *yaml.Node // where the problem is, to tell which file of a merged workflow it comes from
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%d:%d: %s", p.Line+1, p.Column+1, p.Message)
+}
+
+func problemAt(node *yaml.Node, format string, args ...any) Problem {
+	return Problem{
+		Line:      node.Line,
+		Column:    node.Column,
+		EndLine:   node.EndLine,
+		EndColumn: node.EndColumn,
+		Message:   fmt.Sprintf(format, args...),
+		node:      node,
+	}
+}
+
+// Convert a parsed `.flow.yaml` into the settings of a job, checking it against the schema on the way
+// Comments are dropped, keys follow the canonical order of the schema and free-form maps are sorted
+// so the same workflow always gives the same JSON
+func Settings(root *yaml.Node) (Object, []Problem) {
+	c := converter{}
+	value := c.convert(root, Job, "")
+	settings, _ := value.(Object)
+	if settings == nil {
+		settings = Object{}
+	}
+	problems := append(c.problems, checkReferences(root)...)
+	return settings, append(problems, checkRunJobTasks(root)...)
+}
+
+// Tasks and job clusters must be declared once and exist where they are referenced
+func checkReferences(root *yaml.Node) []Problem {
+	problems := []Problem{}
+	declare := func(sequence *yaml.Node, key string) map[string]bool {
+		declared := map[string]bool{}
+		if sequence == nil || sequence.Kind != yaml.SequenceNode {
+			return declared
+		}
+		for _, item := range sequence.Content {
+			name := item.Get(key)
+			if name == nil || name.Kind != yaml.ScalarNode {
+				continue
+			}
+			if declared[name.Value] {
+				problems = append(problems, problemAt(name, "`%s` is declared more than once", name.Value))
+			}
+			declared[name.Value] = true
+		}
+		return declared
+	}
+	tasks := declare(root.Get("tasks"), "task_key")
+	clusters := declare(root.Get("job_clusters"), "job_cluster_key")
+
+	if sequence := root.Get("tasks"); sequence != nil && sequence.Kind == yaml.SequenceNode {
+		for _, task := range sequence.Content {
+			if cluster := task.Get("job_cluster_key"); cluster != nil && cluster.Kind == yaml.ScalarNode && !clusters[cluster.Value] {
+				problems = append(problems, problemAt(cluster, "job cluster `%s` is not declared in `job_clusters`", cluster.Value))
+			}
+			dependencies := task.Get("depends_on")
+			if dependencies == nil || dependencies.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, dependency := range dependencies.Content {
+				if name := dependency.Get("task_key"); name != nil && name.Kind == yaml.ScalarNode && !tasks[name.Value] {
+					problems = append(problems, problemAt(name, "task `%s` is not declared in `tasks`", name.Value))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// Body of a `jobs/create` request
+func CreateRequest(settings Object) Object {
+	return settings
+}
+
+// Body of a `jobs/reset` request, it overwrites all the settings of an existing job
+// Permissions are not settings there, they are left to the permissions API
+func ResetRequest(jobID int64, settings Object) Object {
+	newSettings := Object{}
+	for _, member := range settings {
+		if member.Key != "access_control_list" {
+			newSettings = append(newSettings, member)
+		}
+	}
+	return Object{
+		{Key: "job_id", Value: jobID},
+		{Key: "new_settings", Value: newSettings},
+	}
+}
+
+type converter struct {
+	problems []Problem
+}
+
+func (c *converter) report(node *yaml.Node, format string, args ...any) {
+	c.problems = append(c.problems, problemAt(node, format, args...))
+}
+
+// Name of a field in messages, the root is the document itself
+func describePath(path string) string {
+	if path == "" {
+		return "the workflow"
+	}
+	return fmt.Sprintf("`%s`", path)
+}
+
+func (c *converter) convert(node *yaml.Node, field *Field, path string) any {
+	if node.Kind == yaml.AliasNode {
+		c.report(node, "alias `*%s` must be expanded before converting", node.Value)
+		return nil
+	}
+	if node.Tag != "" {
+		c.report(node, "tag `%s` is not supported", node.Tag)
+		return nil
+	}
+
+	switch field.Type {
+	case ObjectType:
+		return c.convertObject(node, field, path)
+	case MapType:
+		if node.Kind != yaml.MappingNode {
+			c.report(node, "%s must be a mapping, found %s", describePath(path), node.Describe())
+			return nil
+		}
+		keys := []string{}
+		values := map[string]any{}
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if _, found := values[key.Value]; found {
+				c.report(key, "`%s` is declared more than once", key.Value)
+			}
+			keys = append(keys, key.Value)
+			values[key.Value] = c.convert(node.Content[i+1], field.Values, path+"."+key.Value)
+		}
+		slices.Sort(keys)
+		keys = slices.Compact(keys)
+		object := Object{}
+		for _, key := range keys {
+			object = append(object, Member{Key: key, Value: values[key]})
+		}
+		return object
+	case ArrayType:
+		if node.Kind != yaml.SequenceNode {
+			c.report(node, "%s must be a sequence, found %s", describePath(path), node.Describe())
+			return nil
+		}
+		items := []any{}
+		for i, item := range node.Content {
+			items = append(items, c.convert(item, field.Items, fmt.Sprintf("%s[%d]", path, i)))
+		}
+		return items
+	case AnyType:
+		return c.convertAny(node)
+	}
+
+	if node.Kind != yaml.ScalarNode || node.IsNull() {
+		c.report(node, "%s must be a %s, found %s", describePath(path), typeName(field.Type), node.Describe())
+		return nil
+	}
+	value := yaml.Resolve(node)
+	switch field.Type {
+	case StringType:
+		if len(field.Enum) > 0 && !slices.Contains(field.Enum, node.Value) {
+			c.report(node, "`%s` is not a valid %s, expected one of %s", node.Value, describePath(path), strings.Join(field.Enum, " | "))
+		}
+		return node.Value
+	case IntType:
+		if _, ok := value.(int64); !ok {
+			c.report(node, "%s must be an integer, found `%s`", describePath(path), node.Value)
+			return nil
+		}
+	case BoolType:
+		if _, ok := value.(bool); !ok {
+			c.report(node, "%s must be `true` or `false`, found `%s`", describePath(path), node.Value)
+			return nil
+		}
+	}
+	return value
+}
+
+func (c *converter) convertObject(node *yaml.Node, field *Field, path string) any {
+	if node.Kind != yaml.MappingNode {
+		c.report(node, "%s must be a mapping, found %s", describePath(path), node.Describe())
+		return nil
+	}
+
+	values := map[*Field]any{}
+	names := map[string]bool{}
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		known := field.Field(key.Value)
+		if known == nil {
+			c.report(key, "unknown field `%s` in %s", key.Value, describePath(path))
+			continue
+		}
+		if names[known.APIName()] {
+			c.report(key, "`%s` is declared more than once", known.APIName())
+		}
+		names[known.APIName()] = true
+		values[known] = c.convert(node.Content[i+1], known, strings.TrimPrefix(path+"."+key.Value, "."))
+	}
+
+	object := Object{}
+	for _, known := range field.Fields {
+		value, found := values[known]
+		if !found {
+			if known.Required {
+				c.report(node, "`%s` is required in %s", known.Name, describePath(path))
+			}
+			continue
+		}
+		object = append(object, Member{Key: known.APIName(), Value: value})
+	}
+	return object
+}
+
+// Value of a free-form field, mappings are sorted by key
+func (c *converter) convertAny(node *yaml.Node) any {
+	switch node.Kind {
+	case yaml.MappingNode:
+		values := map[string]any{}
+		keys := []string{}
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if _, found := values[key]; !found {
+				keys = append(keys, key)
+			}
+			values[key] = c.convert(node.Content[i+1], &Field{Type: AnyType}, "")
+		}
+		slices.Sort(keys)
+		object := Object{}
+		for _, key := range keys {
+			object = append(object, Member{Key: key, Value: values[key]})
+		}
+		return object
+	case yaml.SequenceNode:
+		items := []any{}
+		for _, item := range node.Content {
+			items = append(items, c.convert(item, &Field{Type: AnyType}, ""))
+		}
+		return items
+	}
+	return yaml.Resolve(node)
+}
+
+func typeName(t Type) string {
+	switch t {
+	case StringType:
+		return "string"
+	case IntType:
+		return "integer"
+	case BoolType:
+		return "boolean"
+	case ObjectType:
+		return "mapping"
+	case ArrayType:
+		return "sequence"
+	case MapType:
+		return "mapping"
+	}
+	return "value"
+}
@@ -0,0 +1,138 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"strings"
+	"testing"
+)
+
+func TestSettings(t *testing.T) {
+	document := `# comments are dropped
+tags:
+  team: jobs
+  cost: 1
+name: "Report"
+tasks:
+  - task_key: "report"
+    max_retries: 2
+    job_cluster_key: "main"
+    notebook_task:
+      notebook_path: "/Shared/report"
+job_clusters:
+  - job_cluster_key: "main"
+    new_cluster:
+      num_workers: 2
+run_as:
+  service_principle_name: "sp"
+`
+	expected := `{
+  "name": "Report",
+  "tags": {
+    "cost": "1",
+    "team": "jobs"
+  },
+  "run_as": {
+    "service_principal_name": "sp"
+  },
+  "tasks": [
+    {
+      "task_key": "report",
+      "job_cluster_key": "main",
+      "notebook_task": {
+        "notebook_path": "/Shared/report"
+      },
+      "max_retries": 2
+    }
+  ],
+  "job_clusters": [
+    {
+      "job_cluster_key": "main",
+      "new_cluster": {
+        "num_workers": 2
+      }
+    }
+  ]
+}
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, problems := workflow.Settings(root)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+
+	var actual strings.Builder
+	if err := workflow.WriteJSON(&actual, workflow.CreateRequest(settings)); err != nil {
+		t.Fatal(err)
+	}
+	if expected != actual.String() {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual.String())
+	}
+}
+
+func TestSettingsProblems(t *testing.T) {
+	document := `name: "Report"
+timeout_seconds: "soon"
+tasks:
+  - task_key: "report"
+    run_if: "SOMETIMES"
+    depends_on:
+      - task_key: "missing"
+    notebok_task: {}
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, problems := workflow.Settings(root)
+
+	actual := []string{}
+	for _, problem := range problems {
+		actual = append(actual, problem.Error())
+	}
+	expected := []string{
+		"2:18: `timeout_seconds` must be an integer, found `soon`",
+		"5:13: `SOMETIMES` is not a valid `tasks[0].run_if`, expected one of ALL_SUCCESS | ALL_DONE | NONE_FAILED | AT_LEAST_ONE_SUCCESS | ALL_FAILED | AT_LEAST_ONE_FAILED",
+		"8:5: unknown field `notebok_task` in `tasks[0]`",
+		"7:19: task `missing` is not declared in `tasks`",
+	}
+	if strings.Join(expected, "\n") != strings.Join(actual, "\n") {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestResetRequest(t *testing.T) {
+	document := `name: "Report"
+access_control_list:
+  - user_name: "someone@example.com"
+    permission_level: "CAN_MANAGE"
+max_concurrent_runs: 1
+`
+	expected := `{
+  "job_id": 42,
+  "new_settings": {
+    "name": "Report",
+    "max_concurrent_runs": 1
+  }
+}
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, problems := workflow.Settings(root)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+
+	var actual strings.Builder
+	if err := workflow.WriteJSON(&actual, workflow.ResetRequest(42, settings)); err != nil {
+		t.Fatal(err)
+	}
+	if expected != actual.String() {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual.String())
+	}
+}
@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+)
+
+// A difference between two versions of a workflow
+// Tasks, job clusters and permissions are matched by identity, so reordering them is not a change
+type Change struct {
+	Kind   ChangeKind
+	Scope  string // `job`, `task`, `job_cluster` or `permission`
+	ID     string // task key, job cluster key or principal, empty for the job
+	Path   string // field that changed, empty when the whole task, cluster or permission is added or removed
+	Before any    // nil when added
+	After  any    // nil when removed
+}
+
+// Entities of a job matched by identity, by field of the settings
+type diffScope struct {
+	field, scope string
+	identity     func(Object) string
+}
+
+var diffScopes = []diffScope{
+	{"tasks", "task", func(o Object) string { s, _ := o.Get("task_key").(string); return s }},
+	{"job_clusters", "job_cluster", func(o Object) string { s, _ := o.Get("job_cluster_key").(string); return s }},
+	{"access_control_list", "permission", principal},
+}
+
+// `user_name:someone@example.com`, the principal a permission is granted to
+func principal(permission Object) string {
+	for _, key := range []string{"user_name", "group_name", "service_principal_name"} {
+		if name, ok := permission.Get(key).(string); ok {
+			return key + ":" + name
+		}
+	}
+	return ""
+}
+
+// Semantic differences between the settings of two versions of a workflow
+// Job settings come first, then tasks, job clusters and permissions
+func Diff(before, after Object) []Change {
+	changes := []Change{}
+	scoped := func(field string) bool {
+		return slices.ContainsFunc(diffScopes, func(s diffScope) bool { return s.field == field })
+	}
+
+	jobBefore, jobAfter := Object{}, Object{}
+	for _, member := range before {
+		if !scoped(member.Key) {
+			jobBefore = append(jobBefore, member)
+		}
+	}
+	for _, member := range after {
+		if !scoped(member.Key) {
+			jobAfter = append(jobAfter, member)
+		}
+	}
+	changes = append(changes, diffValues("job", "", "", jobBefore, jobAfter)...)
+
+	for _, s := range diffScopes {
+		entitiesBefore, order := identify(before.Get(s.field), s.identity)
+		entitiesAfter, orderAfter := identify(after.Get(s.field), s.identity)
+		for _, id := range orderAfter {
+			if !slices.Contains(order, id) {
+				order = append(order, id)
+			}
+		}
+		for _, id := range order {
+			a, inBefore := entitiesBefore[id]
+			b, inAfter := entitiesAfter[id]
+			switch {
+			case !inBefore:
+				changes = append(changes, Change{Kind: Added, Scope: s.scope, ID: id, After: b})
+			case !inAfter:
+				changes = append(changes, Change{Kind: Removed, Scope: s.scope, ID: id, Before: a})
+			case s.scope == "task":
+				changes = append(changes, diffTask(id, a, b)...)
+			default:
+				changes = append(changes, diffValues(s.scope, id, "", a, b)...)
+			}
+		}
+	}
+	return changes
+}
+
+// Entities of a sequence by identity, with the order they are declared in
+func identify(value any, identity func(Object) string) (map[string]Object, []string) {
+	entities := map[string]Object{}
+	order := []string{}
+	items, _ := value.([]any)
+	for _, item := range items {
+		object, _ := item.(Object)
+		id := identity(object)
+		if _, found := entities[id]; found {
+			continue
+		}
+		entities[id] = object
+		order = append(order, id)
+	}
+	return entities, order
+}
+
+// Dependencies of a task are a set, `Before` and `After` are the sorted task keys
+func diffTask(id string, before, after Object) []Change {
+	dependencies := func(task Object) []any {
+		keys := []string{}
+		items, _ := task.Get("depends_on").([]any)
+		for _, item := range items {
+			dependency, _ := item.(Object)
+			key, _ := dependency.Get("task_key").(string)
+			if outcome, ok := dependency.Get("outcome").(string); ok {
+				key += " (" + outcome + ")"
+			}
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		sorted := []any{}
+		for _, key := range slices.Compact(keys) {
+			sorted = append(sorted, key)
+		}
+		return sorted
+	}
+	without := func(task Object) Object {
+		return slices.DeleteFunc(slices.Clone(task), func(m Member) bool { return m.Key == "depends_on" })
+	}
+
+	changes := []Change{}
+	if a, b := dependencies(before), dependencies(after); CompactJSON(a) != CompactJSON(b) {
+		changes = append(changes, Change{Kind: Modified, Scope: "task", ID: id, Path: "depends_on", Before: a, After: b})
+	}
+	return append(changes, diffValues("task", id, "", without(before), without(after))...)
+}
+
+// Field by field differences, objects are compared key by key and anything else as a whole
+func diffValues(scope, id, path string, before, after any) []Change {
+	a, aIsObject := before.(Object)
+	b, bIsObject := after.(Object)
+	if !aIsObject || !bIsObject {
+		switch {
+		case before == nil && after == nil:
+			return nil
+		case before == nil:
+			return []Change{{Kind: Added, Scope: scope, ID: id, Path: path, After: after}}
+		case after == nil:
+			return []Change{{Kind: Removed, Scope: scope, ID: id, Path: path, Before: before}}
+		case CompactJSON(before) != CompactJSON(after):
+			return []Change{{Kind: Modified, Scope: scope, ID: id, Path: path, Before: before, After: after}}
+		}
+		return nil
+	}
+
+	keys := []string{}
+	for _, member := range append(slices.Clone(a), b...) {
+		if !slices.Contains(keys, member.Key) {
+			keys = append(keys, member.Key)
+		}
+	}
+	changes := []Change{}
+	for _, key := range keys {
+		changes = append(changes, diffValues(scope, id, strings.TrimPrefix(path+"."+key, "."), a.Get(key), b.Get(key))...)
+	}
+	return changes
+}
+
+// Value as JSON on a single line
+func CompactJSON(value any) string {
+	switch v := value.(type) {
+	case Object:
+		members := []string{}
+		for _, member := range v {
+			members = append(members, CompactJSON(member.Key)+": "+CompactJSON(member.Value))
+		}
+		return "{" + strings.Join(members, ", ") + "}"
+	case []any:
+		items := []string{}
+		for _, item := range v {
+			items = append(items, CompactJSON(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	var buffer bytes.Buffer
+	if err := writeValue(&buffer, value, ""); err != nil {
+		return fmt.Sprint(value)
+	}
+	return buffer.String()
+}
@@ -0,0 +1,63 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := `name: "Report"
+tasks:
+  - task_key: "ingest"
+  - task_key: "report"
+    depends_on:
+      - task_key: "ingest"
+    max_retries: 1
+access_control_list:
+  - group_name: "users"
+    permission_level: CAN_VIEW
+`
+	// Tasks are reordered, it is not a change
+	after := `name: "Report"
+tasks:
+  - task_key: "report"
+    depends_on:
+      - task_key: "clean"
+    max_retries: 2
+  - task_key: "clean"
+  - task_key: "ingest"
+access_control_list:
+  - group_name: "users"
+    permission_level: CAN_MANAGE
+`
+	settings := []workflow.Object{}
+	for _, document := range []string{before, after} {
+		root, err := yaml.Parse(document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		object, problems := workflow.Settings(root)
+		if len(problems) > 0 {
+			t.Fatal(problems)
+		}
+		settings = append(settings, object)
+	}
+
+	actual := []string{}
+	for _, change := range workflow.Diff(settings[0], settings[1]) {
+		actual = append(actual, fmt.Sprintf("%s %s %s %s %s -> %s", change.Kind, change.Scope, change.ID, change.Path,
+			workflow.CompactJSON(change.Before), workflow.CompactJSON(change.After)))
+	}
+	expected := []string{
+		`modified task report depends_on ["ingest"] -> ["clean"]`,
+		`modified task report max_retries 1 -> 2`,
+		`added task clean  null -> {"task_key": "clean"}`,
+		`modified permission group_name:users permission_level "CAN_VIEW" -> "CAN_MANAGE"`,
+	}
+	if strings.Join(expected, "\n") != strings.Join(actual, "\n") {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
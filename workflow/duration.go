@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var durationUnits = []struct {
+	name   string
+	length time.Duration
+}{
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// Duration for humans, the largest unit and the next one, e.g. `3h 0m` or `1d 2h`
+// Durations under a minute are written in seconds, under a second in milliseconds
+func HumanDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + HumanDuration(-d)
+	}
+	if d == 0 {
+		return "0s"
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	for i, unit := range durationUnits {
+		if d < unit.length {
+			continue
+		}
+		if i == len(durationUnits)-1 {
+			return fmt.Sprintf("%d%s", d/unit.length, unit.name)
+		}
+		next := durationUnits[i+1]
+		return fmt.Sprintf("%d%s %d%s", d/unit.length, unit.name, d%unit.length/next.length, next.name)
+	}
+	return ""
+}
+
+// Unit of a numeric field holding a duration, 0 when the field is not a duration
+// `metric` is the metric of a health rule, its `value` is a duration for the `_SECONDS` metrics
+func DurationUnit(key, metric string) time.Duration {
+	switch {
+	case strings.HasSuffix(key, "_seconds"):
+		return time.Second
+	case strings.HasSuffix(key, "_millis"):
+		return time.Millisecond
+	case strings.HasSuffix(key, "_minutes"):
+		return time.Minute
+	case key == "value" && strings.HasSuffix(metric, "_SECONDS"):
+		return time.Second
+	}
+	return 0
+}
@@ -0,0 +1,278 @@
+package workflow
+
+import (
+	"dbwf-ls/yaml"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Order of the tasks in a formatted document
+type TaskOrder string
+
+const (
+	KeepOrder         TaskOrder = ""
+	AlphabeticalOrder TaskOrder = "alphabetical" // by task key
+	TopologicalOrder  TaskOrder = "topological"  // tasks after their dependencies, in declared order otherwise
+)
+
+// Rewrite a `.flow.yaml` in the canonical style
+// Indentation is 2 spaces with sequences indented under their key, quotes are only kept where needed
+// and double, top-level keys follow the order of the schema and tasks the given order
+// Comments are kept, and so are the empty lines between keys and between items, one at most
+func Format(src string, order TaskOrder) (string, error) {
+	switch order {
+	case KeepOrder, AlphabeticalOrder, TopologicalOrder:
+	default:
+		return "", fmt.Errorf("unknown task order `%s`, expected `%s` or `%s`", order, AlphabeticalOrder, TopologicalOrder)
+	}
+	root, err := yaml.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	if err := flowComments(root); err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(src, "\n")
+	if root.Kind == yaml.MappingNode && len(root.Content) > 0 {
+		// The comment heading the document stays on top, with its empty lines
+		header := headerComment(lines[:root.Content[0].Line])
+		// The empty lines of the header are written with it, not above the key that was first
+		lines = slices.Clone(lines)
+		for i := range root.Content[0].Line {
+			lines[i] = "#"
+		}
+		root.Content[0].HeadComment = ""
+		sortKeys(root, Job)
+		first := root.Content[0]
+		first.HeadComment = strings.TrimSuffix(header+"\n"+first.HeadComment, "\n")
+		if header == "" {
+			first.HeadComment = strings.Trim(first.HeadComment, "\n")
+		}
+		if tasks := root.Get("tasks"); tasks != nil && tasks.Kind == yaml.SequenceNode {
+			orderTasks(tasks, order)
+		}
+	}
+	normalise(root, lines, false)
+	return yaml.Encode(root), nil
+}
+
+// Comment lines above the first key of a document, an empty line at most between them and the key
+func headerComment(lines []string) string {
+	header, blank := []string{}, false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = len(header) > 0
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		if blank {
+			header = append(header, "")
+		}
+		header, blank = append(header, line), false
+	}
+	if blank {
+		header = append(header, "")
+	}
+	return strings.Join(header, "\n")
+}
+
+// Comments inside flow collections have no place once the collection is written on a single line
+func flowComments(n *yaml.Node) error {
+	if n.Style == yaml.FlowStyle && n.InnerComment != "" {
+		return &yaml.Error{Line: n.Line, Column: n.Column, Message: "comments inside `[...]` or `{...}` would be lost, move them out to format the document"}
+	}
+	for _, child := range n.Content {
+		if err := flowComments(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewrite a part of a `.flow.yaml` in the canonical style, e.g. a pasted block
+// Keys and tasks keep their order, and the result is not indented
+func FormatFragment(src string) (string, error) {
+	root, err := yaml.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	if err := flowComments(root); err != nil {
+		return "", err
+	}
+	normalise(root, strings.Split(src, "\n"), false)
+	return yaml.Encode(root), nil
+}
+
+// Keys of a mapping in the order of the schema, unknown keys last in their order
+func sortKeys(mapping *yaml.Node, field *Field) {
+	rank := func(key *yaml.Node) int {
+		index := slices.IndexFunc(field.Fields, func(f *Field) bool { return f.Name == key.Value })
+		if index < 0 {
+			return len(field.Fields)
+		}
+		return index
+	}
+	pairs := [][2]*yaml.Node{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{mapping.Content[i], mapping.Content[i+1]})
+	}
+	slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int { return rank(a[0]) - rank(b[0]) })
+	mapping.Content = mapping.Content[:0]
+	for _, pair := range pairs {
+		mapping.Content = append(mapping.Content, pair[0], pair[1])
+	}
+}
+
+func orderTasks(tasks *yaml.Node, order TaskOrder) {
+	key := func(task *yaml.Node) string {
+		if name := task.Get("task_key"); name != nil {
+			return name.Value
+		}
+		return ""
+	}
+
+	switch order {
+	case AlphabeticalOrder:
+		slices.SortStableFunc(tasks.Content, func(a, b *yaml.Node) int { return strings.Compare(key(a), key(b)) })
+	case TopologicalOrder:
+		// The first task whose dependencies are all placed goes next, tasks in a cycle stay at the end
+		declared := map[string]bool{}
+		for _, task := range tasks.Content {
+			declared[key(task)] = true
+		}
+		placed := map[string]bool{}
+		ready := func(task *yaml.Node) bool {
+			dependencies := task.Get("depends_on")
+			if dependencies == nil {
+				return true
+			}
+			for _, dependency := range dependencies.Content {
+				name := key(dependency)
+				if declared[name] && !placed[name] && name != key(task) {
+					return false
+				}
+			}
+			return true
+		}
+		sorted := []*yaml.Node{}
+		remaining := slices.Clone(tasks.Content)
+		for len(remaining) > 0 {
+			next := slices.IndexFunc(remaining, ready)
+			if next < 0 {
+				break
+			}
+			placed[key(remaining[next])] = true
+			sorted = append(sorted, remaining[next])
+			remaining = slices.Delete(remaining, next, next+1)
+		}
+		tasks.Content = append(sorted, remaining...)
+	}
+}
+
+// Quotes and empty lines of a tree, `lines` are the lines of the source
+// `flow` is set inside flow collections, where more characters need quotes
+func normalise(n *yaml.Node, lines []string, flow bool) {
+	flow = flow || n.Style == yaml.FlowStyle
+	switch n.Kind {
+	case yaml.ScalarNode:
+		normaliseQuotes(n, flow)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			key.BlankLine = i > 0 && !flow && blankAbove(key.Line, lines)
+			normaliseQuotes(key, flow)
+			normalise(value, lines, flow)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			item.BlankLine = i > 0 && !flow && blankAbove(item.Line, lines)
+			normalise(item, lines, flow)
+		}
+	}
+}
+
+// Whether an empty line separates a line and the comments right above it from what comes before
+func blankAbove(line int, lines []string) bool {
+	above := line - 1
+	for above >= 0 && strings.HasPrefix(strings.TrimSpace(lines[above]), "#") {
+		above--
+	}
+	return above >= 0 && strings.TrimSpace(lines[above]) == ""
+}
+
+// Quoted scalars that read the same without quotes lose them, the others are double quoted
+// Plain scalars joined from several lines are quoted when they don't read back on a single one
+func normaliseQuotes(n *yaml.Node, flow bool) {
+	if n.Kind != yaml.ScalarNode || n.Tag != "" {
+		return
+	}
+	if n.Style == yaml.PlainStyle && n.Line != n.EndLine && !readsBack(n.Value, flow) {
+		n.Style = yaml.DoubleQuotedStyle
+	}
+	if n.Style != yaml.SingleQuotedStyle && n.Style != yaml.DoubleQuotedStyle {
+		return
+	}
+	n.Style = yaml.DoubleQuotedStyle
+	if plain(n.Value, flow) {
+		n.Style = yaml.PlainStyle
+	}
+}
+
+// Whether a string can be written as a plain scalar, in a flow collection when `flow` is set
+// It must not start with an indicator, hold `: ` or ` #`, or read as something else than a string
+func plain(value string, flow bool) bool {
+	if value == "" || strings.ContainsAny(value, "\n\t\r") || strings.TrimSpace(value) != value ||
+		strings.ContainsRune(indicators, rune(value[0])) ||
+		strings.Contains(value, ": ") || strings.HasSuffix(value, ":") || strings.Contains(value, " #") {
+		return false
+	}
+	if flow && strings.ContainsAny(value, ",[]{}") {
+		return false
+	}
+	if slices.Contains(yaml11Booleans, strings.ToLower(value)) || yaml11Timestamp.MatchString(value) || yaml11Number.MatchString(value) {
+		return false
+	}
+
+	if !readsBack(value, flow) {
+		return false
+	}
+	resolved, ok := yaml.Resolve(&yaml.Node{Kind: yaml.ScalarNode, Value: value}).(string)
+	return ok && resolved == value
+}
+
+// Whether a string written as a plain scalar on a single line is read back as the same string
+func readsBack(value string, flow bool) bool {
+	if strings.ContainsAny(value, "\n\t\r") {
+		return false
+	}
+	document := "key: " + value
+	if flow {
+		document = "key: [" + value + "]"
+	}
+	root, err := yaml.Parse(document)
+	if err != nil {
+		return false
+	}
+	node := root.Get("key")
+	if flow && node != nil && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	return node != nil && node.Kind == yaml.ScalarNode && node.Style == yaml.PlainStyle && node.Value == value && node.LineComment == ""
+}
+
+// Characters that cannot start a plain scalar
+const indicators = "-?:,[]{}#&*!|>'\"%@`"
+
+// Scalars of YAML 1.1 that are strings for this parser but not for every tool reading the document
+var (
+	yaml11Booleans  = []string{"y", "n", "yes", "no", "on", "off"}
+	yaml11Timestamp = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}`)
+	// Binary, octal with a leading 0, hexadecimal, sexagesimal and `_` separated numbers
+	yaml11Number = regexp.MustCompile(`^[-+]?(0b[01_]+|0x[0-9a-fA-F_]+|0o?[0-7_]+|[0-9][0-9_]*(:[0-5]?[0-9])*(\.[0-9_]*)?([eE][-+]?[0-9]+)?|\.[0-9_]+([eE][-+]?[0-9]+)?|\.(inf|Inf|INF))$|^\.(nan|NaN|NAN)$`)
+)
@@ -0,0 +1,247 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"strings"
+	"testing"
+)
+
+const unformatted = `# Nightly job
+tasks:
+    # Runs last
+    - task_key: 'report'
+      depends_on:
+          - task_key: ingest
+      notebook_task: {notebook_path: "/Shared/report"}
+
+
+    - task_key: "ingest"   # first
+      description: 'it''s "fine"'
+      spark_python_task:
+         python_file: ./ingest.py
+         parameters: ["--date", '{{job.start_time.iso_date}}', "yes"]
+name: "Nightly"
+tags:
+   team: 'data'
+
+   cost: "123"
+timeout_seconds: 3600
+`
+
+func TestFormat(t *testing.T) {
+	expected := `# Nightly job
+name: Nightly
+tags:
+  team: data
+
+  cost: "123"
+timeout_seconds: 3600
+tasks:
+  # Runs last
+  - task_key: report
+    depends_on:
+      - task_key: ingest
+    notebook_task: { notebook_path: /Shared/report }
+
+  - task_key: ingest # first
+    description: it's "fine"
+    spark_python_task:
+      python_file: ./ingest.py
+      parameters: ["--date", "{{job.start_time.iso_date}}", "yes"]
+`
+	actual, err := workflow.Format(unformatted, workflow.KeepOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestFormatTaskOrder(t *testing.T) {
+	tests := map[workflow.TaskOrder]string{
+		workflow.KeepOrder:         "report ingest",
+		workflow.AlphabeticalOrder: "ingest report",
+		workflow.TopologicalOrder:  "ingest report",
+	}
+	for order, expected := range tests {
+		formatted, err := workflow.Format(unformatted, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := yaml.Parse(formatted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		settings, _ := workflow.Settings(root)
+		keys := ""
+		for _, graphTask := range workflow.NewGraph(settings).Tasks {
+			keys += " " + graphTask.Key
+		}
+		if actual := keys[1:]; expected != actual {
+			t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+		}
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	for _, order := range []workflow.TaskOrder{workflow.KeepOrder, workflow.AlphabeticalOrder, workflow.TopologicalOrder} {
+		once, err := workflow.Format(unformatted, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		twice, err := workflow.Format(once, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if once != twice {
+			t.Fatalf("Expected: %s, Actual: %s", once, twice)
+		}
+	}
+
+	// Block scalars keep their value: more indented lines of a folded scalar keep their line breaks,
+	// a folded scalar may start with an empty line and an empty one has no lines to write
+	tests := []struct {
+		document, expected string
+	}{
+		{
+			"description: >\n  Runs the job:\n    - step one\n    - step two\n\n  Then stops\n  for good\nname: job\n",
+			"name: job\ndescription: >\n  Runs the job:\n    - step one\n    - step two\n\n  Then stops for good\n",
+		},
+		{
+			"description: >\n\n  Starts empty\n  and folds\n",
+			"description: >2\n\n  Starts empty and folds\n",
+		},
+		{
+			"description: |\nname: job\n",
+			"name: job\ndescription: \"\"\n",
+		},
+	}
+	for _, test := range tests {
+		before, err := yaml.Parse(test.document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := workflow.Format(test.document, workflow.KeepOrder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.expected != once {
+			t.Fatalf("Expected: %s, Actual: %s", test.expected, once)
+		}
+		after, err := yaml.Parse(once)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := before.Get("description").Value, after.Get("description").Value; expected != actual {
+			t.Fatalf("Expected: %q, Actual: %q", expected, actual)
+		}
+		twice, err := workflow.Format(once, workflow.KeepOrder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if once != twice {
+			t.Fatalf("Expected: %s, Actual: %s", once, twice)
+		}
+	}
+}
+
+func TestFormatQuotes(t *testing.T) {
+	tests := []struct {
+		document, expected string
+	}{
+		{`a: ["a,b"]`, `a: ["a,b"]`},
+		{`a: ["x]"]`, `a: ["x]"]`},
+		{`a: ["{x}", "[x"]`, `a: ["{x}", "[x"]`},
+		{`a: "a,b"`, `a: a,b`},
+		{`a: "x]"`, `a: x]`},
+		{`a: "a: b"`, `a: "a: b"`},
+		{`a: ["a: b"]`, `a: ["a: b"]`},
+		{`a: "a:"`, `a: "a:"`},
+		{`a: "a #b"`, `a: "a #b"`},
+		{`a: "a:b#c"`, `a: a:b#c`},
+		{`a: "@x"`, `a: "@x"`},
+		{"a: \"`x\"", "a: \"`x\""},
+		{`a: "%x"`, `a: "%x"`},
+		{`a: "-x"`, `a: "-x"`},
+		{`a: "&x"`, `a: "&x"`},
+		{`a: "!x"`, `a: "!x"`},
+		{`a: "2024-01-01"`, `a: "2024-01-01"`},
+		{`a: "2024-1-1 10:00:00"`, `a: "2024-1-1 10:00:00"`},
+		{`a: "0b101"`, `a: "0b101"`},
+		{`a: "0x1F"`, `a: "0x1F"`},
+		{`a: "017"`, `a: "017"`},
+		{`a: "1_000"`, `a: "1_000"`},
+		{`a: "1:30"`, `a: "1:30"`},
+		{`a: "yes"`, `a: "yes"`},
+		{`a: "Off"`, `a: "Off"`},
+		{`a: "2024-report"`, `a: 2024-report`},
+		{`a: 'report'`, `a: report`},
+	}
+	for _, test := range tests {
+		actual, err := workflow.Format(test.document, workflow.KeepOrder)
+		if err != nil {
+			t.Fatalf("%s: %s", test.document, err)
+		}
+		if actual = strings.TrimSuffix(actual, "\n"); test.expected != actual {
+			t.Fatalf("Expected: %s, Actual: %s", test.expected, actual)
+		}
+		twice, err := workflow.Format(actual, workflow.KeepOrder)
+		if err != nil {
+			t.Fatalf("%s: %s", actual, err)
+		}
+		if twice = strings.TrimSuffix(twice, "\n"); actual != twice {
+			t.Fatalf("Expected: %s, Actual: %s", actual, twice)
+		}
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	tests := []struct {
+		document, expected string
+	}{
+		// Comment after the dash of a task
+		{
+			"tasks:\n  - # first task\n    task_key: a\n  -   # second\n    task_key: b\n",
+			"tasks:\n  - # first task\n    task_key: a\n  - # second\n    task_key: b\n",
+		},
+		// Empty lines of the header, the header stays on top of the sorted keys
+		{
+			"# Nightly\n\n\n# job\n\ntags: {}\nname: job\n",
+			"# Nightly\n\n# job\n\nname: job\ntags: {}\n",
+		},
+		{
+			"# Nightly\nname: job\n",
+			"# Nightly\nname: job\n",
+		},
+		// Plain values on several lines are joined on one
+		{
+			"description: Runs\n  - the job\n  every day\n",
+			"description: Runs - the job every day\n",
+		},
+	}
+	for _, test := range tests {
+		actual, err := workflow.Format(test.document, workflow.KeepOrder)
+		if err != nil {
+			t.Fatalf("%s: %s", test.document, err)
+		}
+		if test.expected != actual {
+			t.Fatalf("Expected: %s, Actual: %s", test.expected, actual)
+		}
+		if twice, err := workflow.Format(actual, workflow.KeepOrder); err != nil || actual != twice {
+			t.Fatalf("Expected: %s, Actual: %s %v", actual, twice, err)
+		}
+	}
+
+	// Comments inside flow collections can't be kept on a single line
+	for _, document := range []string{
+		"job_clusters: [ {job_cluster_key: k},  # note\n ]\n",
+		"tags: {team: data, # owner\n  cost: \"1\"}\n",
+		"tasks:\n  - depends_on: [{task_key: a}, {\n      # first\n      task_key: b}]\n",
+	} {
+		if _, err := workflow.Format(document, workflow.KeepOrder); err == nil || !strings.Contains(err.Error(), "comments inside") {
+			t.Fatalf("Expected: comments inside ..., Actual: %v", err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Task dependency graph of a workflow
+type Graph struct {
+	Tasks []GraphTask
+	Edges []GraphEdge
+}
+
+type GraphTask struct {
+	Key     string
+	Type    string // e.g. `notebook` for a `notebook_task`, empty when the task has none
+	Cluster string // job cluster, existing cluster or environment the task runs on
+}
+
+// Dependency of a task, `From` runs first
+type GraphEdge struct {
+	From, To string
+	Label    string // non-default `run_if` of the task and outcome of the dependency
+}
+
+// Graph of the tasks of a job, dependencies on undeclared tasks are left out
+func NewGraph(settings Object) Graph {
+	graph := Graph{}
+	tasks, _ := settings.Get("tasks").([]any)
+	declared := map[string]bool{}
+	for _, item := range tasks {
+		task, _ := item.(Object)
+		if key, ok := task.Get("task_key").(string); ok && !declared[key] {
+			declared[key] = true
+			graph.Tasks = append(graph.Tasks, GraphTask{Key: key, Type: taskType(task), Cluster: taskCluster(task)})
+		}
+	}
+
+	for _, item := range tasks {
+		task, _ := item.(Object)
+		key, _ := task.Get("task_key").(string)
+		runIf, _ := task.Get("run_if").(string)
+		if runIf == "ALL_SUCCESS" {
+			runIf = ""
+		}
+		dependencies, _ := task.Get("depends_on").([]any)
+		for _, dependency := range dependencies {
+			dependency, _ := dependency.(Object)
+			from, _ := dependency.Get("task_key").(string)
+			if !declared[from] || !declared[key] {
+				continue
+			}
+			labels := []string{}
+			if outcome, ok := dependency.Get("outcome").(string); ok {
+				labels = append(labels, outcome)
+			}
+			if runIf != "" {
+				labels = append(labels, runIf)
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{From: from, To: key, Label: strings.Join(labels, ", ")})
+		}
+	}
+	return graph
+}
+
+// `notebook` for a `notebook_task`
+func taskType(task Object) string {
+	for _, member := range task {
+		if strings.HasSuffix(member.Key, "_task") {
+			return strings.TrimSuffix(member.Key, "_task")
+		}
+	}
+	return ""
+}
+
+func taskCluster(task Object) string {
+	for _, key := range []string{"job_cluster_key", "existing_cluster_id", "environment_key"} {
+		if cluster, ok := task.Get(key).(string); ok {
+			return cluster
+		}
+	}
+	if task.Get("new_cluster") != nil {
+		return "new cluster"
+	}
+	return ""
+}
+
+// Longest chain of dependent tasks, ties go to the tasks declared first
+// Tasks in a dependency cycle are not part of it
+func (g Graph) CriticalPath() []string {
+	upstream := map[string][]string{}
+	for _, edge := range g.Edges {
+		upstream[edge.To] = append(upstream[edge.To], edge.From)
+	}
+
+	// Longest chain ending at each task
+	longest := map[string][]string{}
+	visiting := map[string]bool{}
+	var chain func(key string) []string
+	chain = func(key string) []string {
+		if path, found := longest[key]; found {
+			return path
+		}
+		if visiting[key] {
+			return nil
+		}
+		visiting[key] = true
+		best := []string{}
+		for _, from := range upstream[key] {
+			if path := chain(from); len(path) > len(best) {
+				best = path
+			}
+		}
+		visiting[key] = false
+		longest[key] = append(slices.Clone(best), key)
+		return longest[key]
+	}
+
+	critical := []string{}
+	for _, task := range g.Tasks {
+		if path := chain(task.Key); len(path) > len(critical) {
+			critical = path
+		}
+	}
+	return critical
+}
+
+// Whether an edge is part of a path, a path goes through consecutive tasks
+func onPath(path []string, edge GraphEdge) bool {
+	for i := 0; i+1 < len(path); i++ {
+		if path[i] == edge.From && path[i+1] == edge.To {
+			return true
+		}
+	}
+	return false
+}
+
+func (t GraphTask) details() string {
+	details := []string{}
+	if t.Type != "" {
+		details = append(details, t.Type)
+	}
+	if t.Cluster != "" {
+		details = append(details, t.Cluster)
+	}
+	return strings.Join(details, " · ")
+}
+
+// Mermaid flowchart, tasks on the critical path are highlighted when asked
+func (g Graph) Mermaid(critical bool) string {
+	var chart strings.Builder
+	chart.WriteString("flowchart TD\n")
+	ids := map[string]string{}
+	escape := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	for i, task := range g.Tasks {
+		ids[task.Key] = fmt.Sprintf("t%d", i)
+		label := escape.Replace(task.Key)
+		if details := task.details(); details != "" {
+			label += "<br/>" + escape.Replace(details)
+		}
+		fmt.Fprintf(&chart, "  %s[\"%s\"]\n", ids[task.Key], label)
+	}
+	for _, edge := range g.Edges {
+		if edge.Label != "" {
+			fmt.Fprintf(&chart, "  %s -->|\"%s\"| %s\n", ids[edge.From], escape.Replace(edge.Label), ids[edge.To])
+		} else {
+			fmt.Fprintf(&chart, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+		}
+	}
+
+	if !critical {
+		return chart.String()
+	}
+	path := g.CriticalPath()
+	if len(path) == 0 {
+		return chart.String()
+	}
+	chart.WriteString("  classDef critical stroke:#d33,stroke-width:3px\n")
+	nodes := []string{}
+	for _, key := range path {
+		nodes = append(nodes, ids[key])
+	}
+	fmt.Fprintf(&chart, "  class %s critical\n", strings.Join(nodes, ","))
+	for i, edge := range g.Edges {
+		if onPath(path, edge) {
+			fmt.Fprintf(&chart, "  linkStyle %d stroke:#d33,stroke-width:3px\n", i)
+		}
+	}
+	return chart.String()
+}
+
+// Graphviz DOT digraph, tasks on the critical path are highlighted when asked
+func (g Graph) DOT(critical bool) string {
+	var dot strings.Builder
+	dot.WriteString("digraph workflow {\n  rankdir=TB;\n  node [shape=box];\n")
+	path := []string{}
+	if critical {
+		path = g.CriticalPath()
+	}
+	quote := func(text string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+	}
+	for _, task := range g.Tasks {
+		label := task.Key
+		if details := task.details(); details != "" {
+			label += "\n" + details
+		}
+		attributes := "label=" + quote(label)
+		if slices.Contains(path, task.Key) {
+			attributes += ", color=red, penwidth=2"
+		}
+		fmt.Fprintf(&dot, "  %s [%s];\n", quote(task.Key), attributes)
+	}
+	for _, edge := range g.Edges {
+		attributes := []string{}
+		if edge.Label != "" {
+			attributes = append(attributes, "label="+quote(edge.Label))
+		}
+		if onPath(path, edge) {
+			attributes = append(attributes, "color=red", "penwidth=2")
+		}
+		fmt.Fprintf(&dot, "  %s -> %s", quote(edge.From), quote(edge.To))
+		if len(attributes) > 0 {
+			fmt.Fprintf(&dot, " [%s]", strings.Join(attributes, ", "))
+		}
+		dot.WriteString(";\n")
+	}
+	dot.WriteString("}\n")
+	return dot.String()
+}
@@ -0,0 +1,64 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"strings"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	document := `tasks:
+  - task_key: "ingest"
+    job_cluster_key: "main"
+    notebook_task:
+      notebook_path: "/ingest"
+  - task_key: "check"
+    depends_on:
+      - task_key: "ingest"
+    condition_task:
+      op: EQUAL_TO
+      left: "1"
+      right: "1"
+  - task_key: "report"
+    depends_on:
+      - task_key: "check"
+        outcome: "true"
+  - task_key: "cleanup"
+    run_if: ALL_DONE
+    depends_on:
+      - task_key: "ingest"
+job_clusters:
+  - job_cluster_key: "main"
+    new_cluster:
+      num_workers: 1
+`
+	expected := `flowchart TD
+  t0["ingest<br/>notebook · main"]
+  t1["check<br/>condition"]
+  t2["report"]
+  t3["cleanup"]
+  t0 --> t1
+  t1 -->|"true"| t2
+  t0 -->|"ALL_DONE"| t3
+  classDef critical stroke:#d33,stroke-width:3px
+  class t0,t1,t2 critical
+  linkStyle 0 stroke:#d33,stroke-width:3px
+  linkStyle 1 stroke:#d33,stroke-width:3px
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, problems := workflow.Settings(root)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	graph := workflow.NewGraph(settings)
+	if path := strings.Join(graph.CriticalPath(), " "); path != "ingest check report" {
+		t.Fatalf("Expected: ingest check report, Actual: %s", path)
+	}
+	if actual := graph.Mermaid(true); expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
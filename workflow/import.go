@@ -0,0 +1,176 @@
+package workflow
+
+import (
+	"bytes"
+	"dbwf-ls/yaml"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Fields of `jobs/get` that are set by Databricks, they can't be part of the settings
+var readOnlyFields = []string{
+	"job_id",
+	"created_time",
+	"creator_user_name",
+	"run_as_user_name",
+	"run_as_owner",
+	"effective_budget_policy_id",
+	"trigger_state",
+	"has_more",
+	"next_page_token",
+}
+
+// Empty objects that still mean something, e.g. `continuous: {}` runs the job continuously
+var keepEmpty = []string{"continuous"}
+
+// Turn the JSON of a job into a `.flow.yaml` document
+// It takes the output of `jobs/get` or of the Jobs UI, the body of a `jobs/create` request
+// or the `new_settings` of a `jobs/reset` one
+// Read-only fields are dropped, keys follow the canonical order of the schema and durations are commented
+func Import(data []byte) (*yaml.Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid job JSON: %w", err)
+	}
+	job, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid job JSON: expected an object")
+	}
+
+	jobID, _ := job["job_id"].(json.Number)
+	settings := job
+	for _, wrapper := range []string{"settings", "new_settings"} {
+		if wrapped, ok := job[wrapper].(map[string]any); ok {
+			settings = wrapped
+			break
+		}
+	}
+
+	// Outside of `run_as`, the runner of the job is only known by its name
+	if _, found := settings["run_as"]; !found {
+		runAs, _ := job["run_as_user_name"].(string)
+		creator, _ := job["creator_user_name"].(string)
+		if runAs != "" && runAs != creator {
+			key := "service_principal_name"
+			if strings.Contains(runAs, "@") {
+				key = "user_name"
+			}
+			settings["run_as"] = map[string]any{key: runAs}
+		}
+	}
+	for _, field := range readOnlyFields {
+		delete(settings, field)
+	}
+	if settings["format"] == "MULTI_TASK" {
+		delete(settings, "format")
+	}
+
+	root := importValue(settings, Job)
+	header := "# Imported from a Databricks job"
+	if jobID != "" {
+		header = fmt.Sprintf("# Imported from Databricks job %s", jobID)
+	}
+	if len(root.Content) > 0 {
+		root.Content[0].HeadComment = header
+	} else {
+		root.FootComment = header
+	}
+	return root, nil
+}
+
+func importValue(value any, field *Field) *yaml.Node {
+	if field == nil {
+		field = &Field{Type: AnyType}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range importKeys(value, field) {
+			child := field.Field(key)
+			if field.Type == MapType {
+				child = field.Values
+			}
+			if isEmpty(value[key]) && !slices.Contains(keepEmpty, key) {
+				continue
+			}
+			item := importValue(value[key], child)
+			item.LineComment = durationComment(key, value)
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, item)
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range value {
+			node.Content = append(node.Content, importValue(item, field.Items))
+		}
+		return node
+	case string:
+		if strings.Contains(value, "\n") && !strings.ContainsAny(value, "\r\x00") {
+			return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.LiteralStyle, Value: value}
+		}
+		if plain(value, false) {
+			return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: value}
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(value)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: "null"}
+}
+
+// Keys of an object in the order of the schema, then unknown keys sorted
+func importKeys(value map[string]any, field *Field) []string {
+	keys := []string{}
+	if field.Type == ObjectType {
+		for _, known := range field.Fields {
+			if _, found := value[known.Name]; found && known.Alias == "" {
+				keys = append(keys, known.Name)
+			}
+		}
+	}
+	unknown := []string{}
+	for key := range value {
+		if !slices.Contains(keys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+	return append(keys, unknown...)
+}
+
+// Nulls, empty objects and empty arrays carry nothing, the API returns a lot of them
+func isEmpty(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		return len(value) == 0
+	case []any:
+		return len(value) == 0
+	}
+	return false
+}
+
+// `# 3h 0m` next to the fields holding a duration
+func durationComment(key string, object map[string]any) string {
+	number, ok := object[key].(json.Number)
+	if !ok {
+		return ""
+	}
+	amount, err := number.Int64()
+	metric, _ := object["metric"].(string)
+	unit := DurationUnit(key, metric)
+	if err != nil || amount <= 0 || unit == 0 {
+		return ""
+	}
+	return "# " + HumanDuration(time.Duration(amount)*unit)
+}
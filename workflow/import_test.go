@@ -0,0 +1,89 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"testing"
+	"time"
+)
+
+func TestImport(t *testing.T) {
+	job := `{
+  "job_id": 42,
+  "creator_user_name": "someone@example.com",
+  "run_as_user_name": "someone@example.com",
+  "created_time": 1700000000000,
+  "settings": {
+    "tasks": [
+      {
+        "timeout_seconds": 5400,
+        "notebook_task": {"notebook_path": "/Shared/report"},
+        "task_key": "report",
+        "description": "Weekly\nreport"
+      }
+    ],
+    "format": "MULTI_TASK",
+    "email_notifications": {},
+    "tags": {"team": "jobs", "cost": "1", "owner": "@data"},
+    "name": "Report"
+  }
+}`
+	expected := `# Imported from Databricks job 42
+name: Report
+tags:
+  cost: "1"
+  owner: "@data"
+  team: jobs
+tasks:
+  - task_key: report
+    description: |-
+      Weekly
+      report
+    notebook_task:
+      notebook_path: /Shared/report
+    timeout_seconds: 5400 # 1h 30m
+`
+	root, err := workflow.Import([]byte(job))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := yaml.Encode(root); expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		3 * time.Hour:                  "3h 0m",
+		26 * time.Hour:                 "1d 2h",
+		90 * time.Second:               "1m 30s",
+		45 * time.Second:               "45s",
+		250 * time.Millisecond:         "250ms",
+		0:                              "0s",
+		-(2*time.Minute + time.Second): "-2m 1s",
+	}
+	for duration, expected := range tests {
+		if actual := workflow.HumanDuration(duration); expected != actual {
+			t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+		}
+	}
+}
+
+func TestDurationUnit(t *testing.T) {
+	tests := []struct {
+		key, metric string
+		expected    time.Duration
+	}{
+		{"timeout_seconds", "", time.Second},
+		{"min_retry_interval_millis", "", time.Millisecond},
+		{"autotermination_minutes", "", time.Minute},
+		{"value", "RUN_DURATION_SECONDS", time.Second},
+		{"value", "STREAMING_BACKLOG_RECORDS", 0},
+		{"max_retries", "", 0},
+	}
+	for _, test := range tests {
+		if actual := workflow.DurationUnit(test.key, test.metric); test.expected != actual {
+			t.Fatalf("Expected: %s, Actual: %s", test.expected, actual)
+		}
+	}
+}
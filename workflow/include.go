@@ -0,0 +1,145 @@
+package workflow
+
+import (
+	"dbwf-ls/yaml"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A file included by a workflow
+type Included struct {
+	File string     // path of the file
+	Root *yaml.Node // tree of the file with its aliases expanded, its own `include` left in
+	From *yaml.Node // path in the `include` of the workflow leading to the file, directly or through other includes
+}
+
+// Paths of the `include` of a workflow, a single path or a sequence of paths
+func includePaths(root *yaml.Node) []*yaml.Node {
+	include := root.Get("include")
+	switch {
+	case include == nil:
+		return nil
+	case include.Kind == yaml.ScalarNode && !include.IsNull():
+		return []*yaml.Node{include}
+	case include.Kind == yaml.SequenceNode:
+		return include.Content
+	}
+	return nil
+}
+
+// Merge the files a workflow includes into it, `file` is the path of the workflow and `read` reads a file
+// Paths start from the folder of the file including them and included files may include others.
+// Included files are merged in order and before the keys of the workflow: a key of the workflow replaces the same key
+// of an included file, except sequences like `job_clusters` or `access_control_list` that add up.
+// A file is only merged once, an include leading back to a file including it is a problem.
+// Problems are at the paths of the `include` of the workflow
+// The trees are reused, the merged workflow has no `include`
+func ResolveIncludes(root *yaml.Node, file string, read func(path string) (string, error)) (*yaml.Node, []Included, []Problem) {
+	included := []Included{}
+	problems := []Problem{}
+	visited := map[string]bool{}
+
+	var resolve func(root *yaml.Node, file string, stack []string, from *yaml.Node) *yaml.Node
+	resolve = func(root *yaml.Node, file string, stack []string, from *yaml.Node) *yaml.Node {
+		merged := &yaml.Node{Kind: yaml.MappingNode, Line: root.Line, Column: root.Column, EndLine: root.EndLine, EndColumn: root.EndColumn}
+		for _, path := range includePaths(root) {
+			top := from
+			if top == nil {
+				top = path
+			}
+			target := path.Value
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(file), target)
+			}
+			if index := slices.Index(stack, target); index >= 0 {
+				cycle := []string{}
+				for _, f := range append(stack[index:], target) {
+					cycle = append(cycle, "`"+filepath.Base(f)+"`")
+				}
+				problems = append(problems, problemAt(top, "include cycle %s", strings.Join(cycle, " -> ")))
+				continue
+			}
+			if visited[target] {
+				continue
+			}
+			visited[target] = true
+
+			content, err := read(target)
+			if err != nil {
+				problems = append(problems, problemAt(top, "cannot include `%s`: %s", path.Value, err))
+				continue
+			}
+			fragment, err := yaml.Parse(content)
+			if err == nil {
+				fragment, err = yaml.Expand(fragment)
+			}
+			if err != nil {
+				problems = append(problems, problemAt(top, "cannot include `%s`: %s", path.Value, err))
+				continue
+			}
+			if fragment.Kind != yaml.MappingNode {
+				problems = append(problems, problemAt(top, "`%s` must be a mapping of job fields, found %s", path.Value, fragment.Describe()))
+				continue
+			}
+			included = append(included, Included{File: target, Root: fragment, From: top})
+			mergeKeys(merged, resolve(fragment, target, append(stack, target), top))
+		}
+		own := &yaml.Node{Kind: yaml.MappingNode}
+		if root.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if root.Content[i].Value != "include" {
+					own.Content = append(own.Content, root.Content[i], root.Content[i+1])
+				}
+			}
+		}
+		mergeKeys(merged, own)
+		return merged
+	}
+
+	if root.Kind != yaml.MappingNode || root.Get("include") == nil {
+		return root, included, problems
+	}
+	return resolve(root, file, []string{file}, nil), included, problems
+}
+
+// Included file a problem of a merged workflow is in, the positions of the problem are in this file
+// Not found when the problem is in the workflow itself
+func IncludedFile(problem Problem, included []Included) (Included, bool) {
+	var within func(n *yaml.Node) bool
+	within = func(n *yaml.Node) bool {
+		if n == problem.node {
+			return true
+		}
+		return slices.ContainsFunc(n.Content, within)
+	}
+	for _, file := range included {
+		if problem.node != nil && within(file.Root) {
+			return file, true
+		}
+	}
+	return Included{}, false
+}
+
+// Keys of a mapping over the keys of another, sequences add up
+func mergeKeys(into, from *yaml.Node) {
+	for i := 0; i+1 < len(from.Content); i += 2 {
+		key, value := from.Content[i], from.Content[i+1]
+		index := -1
+		for j := 0; j+1 < len(into.Content); j += 2 {
+			if into.Content[j].Value == key.Value {
+				index = j
+			}
+		}
+		switch {
+		case index < 0:
+			into.Content = append(into.Content, key, value)
+		case into.Content[index+1].Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			sequence := *value
+			sequence.Content = append(slices.Clone(into.Content[index+1].Content), value.Content...)
+			into.Content[index], into.Content[index+1] = key, &sequence
+		default:
+			into.Content[index], into.Content[index+1] = key, value
+		}
+	}
+}
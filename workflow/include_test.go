@@ -0,0 +1,93 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	files := map[string]string{
+		"/repo/shared/clusters.yaml": `include: notify.yaml
+job_clusters:
+  - job_cluster_key: shared
+timeout_seconds: 60
+`,
+		"/repo/shared/notify.yaml": `email_notifications:
+  on_failure: [team@example.com]
+`,
+		"/repo/shared/loop.yaml": "include: ../jobs/job.flow.yaml\n",
+	}
+	read := func(path string) (string, error) {
+		if content, found := files[path]; found {
+			return content, nil
+		}
+		return "", errors.New("not found")
+	}
+	document := `include:
+  - ../shared/clusters.yaml
+  - ../shared/loop.yaml
+  - ../shared/clusters.yaml
+name: job
+timeout_seconds: 120
+job_clusters:
+  - job_cluster_key: own
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, included, problems := workflow.ResolveIncludes(root, "/repo/jobs/job.flow.yaml", read)
+
+	actual := []string{}
+	for _, problem := range problems {
+		actual = append(actual, problem.Error())
+	}
+	expected := []string{"3:5: include cycle `job.flow.yaml` -> `loop.yaml` -> `job.flow.yaml`"}
+	if strings.Join(expected, "\n") != strings.Join(actual, "\n") {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+	if expected, actual := 3, len(included); expected != actual {
+		t.Fatalf("Expected: %d, Actual: %d", expected, actual)
+	}
+
+	// Keys of the workflow win, sequences add up, included files come first
+	expectedDocument := `email_notifications:
+  on_failure: [team@example.com]
+job_clusters:
+  - job_cluster_key: shared
+  - job_cluster_key: own
+timeout_seconds: 120
+name: job
+`
+	if actualDocument := yaml.Encode(merged); expectedDocument != actualDocument {
+		t.Fatalf("Expected: %s, Actual: %s", expectedDocument, actualDocument)
+	}
+}
+
+func TestIncludedFile(t *testing.T) {
+	read := func(path string) (string, error) {
+		return "timeout_seconds: soon\n", nil
+	}
+	root, err := yaml.Parse("include: shared.yaml\nmax_concurrent_runs: many\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, included, _ := workflow.ResolveIncludes(root, "/repo/job.flow.yaml", read)
+	_, problems := workflow.Settings(merged)
+
+	files := []string{}
+	for _, problem := range problems {
+		file := "/repo/job.flow.yaml"
+		if from, found := workflow.IncludedFile(problem, included); found {
+			file = from.File
+		}
+		files = append(files, file+":"+problem.Error())
+	}
+	expected := []string{"/repo/shared.yaml:1:18: ", "/repo/job.flow.yaml:2:22: "}
+	if len(expected) != len(files) || !strings.HasPrefix(files[0], expected[0]) || !strings.HasPrefix(files[1], expected[1]) {
+		t.Fatalf("Expected: %s, Actual: %s", expected, files)
+	}
+}
@@ -0,0 +1,106 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSON object keeping the order of its keys
+// Values are Object, []any, string, int64, float64, bool or nil
+type Object []Member
+
+type Member struct {
+	Key   string
+	Value any
+}
+
+// Value of a key, nil when the key is missing
+func (o Object) Get(key string) any {
+	for _, member := range o {
+		if member.Key == key {
+			return member.Value
+		}
+	}
+	return nil
+}
+
+// Write a value as indented JSON, keys stay in their order and nothing is HTML escaped
+func WriteJSON(w io.Writer, value any) error {
+	var buffer bytes.Buffer
+	if err := writeValue(&buffer, value, ""); err != nil {
+		return err
+	}
+	buffer.WriteByte('\n')
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+func writeValue(buffer *bytes.Buffer, value any, indent string) error {
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		buffer.WriteString(strconv.FormatBool(v))
+	case int64:
+		buffer.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Errorf("%v cannot be written as JSON", v)
+		}
+		buffer.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case string:
+		writeString(buffer, v)
+	case []any:
+		if len(v) == 0 {
+			buffer.WriteString("[]")
+			return nil
+		}
+		buffer.WriteString("[\n")
+		for i, item := range v {
+			buffer.WriteString(indent + "  ")
+			if err := writeValue(buffer, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteByte('\n')
+		}
+		buffer.WriteString(indent + "]")
+	case Object:
+		if len(v) == 0 {
+			buffer.WriteString("{}")
+			return nil
+		}
+		buffer.WriteString("{\n")
+		for i, member := range v {
+			buffer.WriteString(indent + "  ")
+			writeString(buffer, member.Key)
+			buffer.WriteString(": ")
+			if err := writeValue(buffer, member.Value, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteByte('\n')
+		}
+		buffer.WriteString(indent + "}")
+	default:
+		return fmt.Errorf("%T cannot be written as JSON", value)
+	}
+	return nil
+}
+
+func writeString(buffer *bytes.Buffer, s string) {
+	var encoded strings.Builder
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	buffer.WriteString(strings.TrimSuffix(encoded.String(), "\n"))
+}
@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"dbwf-ls/yaml"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A `run_job_task` triggers another job by `job_id`, or by `job_name` when the job is another workflow of the project
+// The name is the `name` of the job or the path of its `.flow.yaml`, it becomes a `job_id` when converting
+
+// Every `run_job_task` needs either a `job_id` or a `job_name`
+func checkRunJobTasks(root *yaml.Node) []Problem {
+	problems := []Problem{}
+	for _, run := range RunJobTasks(root) {
+		id, name := run.Get("job_id"), run.Get("job_name")
+		switch {
+		case id == nil && name == nil:
+			problems = append(problems, problemAt(run, "`job_id` or `job_name` is required in `run_job_task`"))
+		case id != nil && name != nil:
+			problems = append(problems, problemAt(name, "`job_name` and `job_id` can't be both given"))
+		}
+	}
+	return problems
+}
+
+// Name of the job of a workflow, the name of its file when the job has none, e.g. `nightly` for `nightly.flow.yaml`
+func JobName(root *yaml.Node, file string) string {
+	if name := root.Get("name"); name != nil && name.Kind == yaml.ScalarNode && name.Value != "" {
+		return name.Value
+	}
+	return strings.TrimSuffix(filepath.Base(file), ".flow.yaml")
+}
+
+// `run_job_task` mappings of the tasks of a workflow, nested `for_each_task` tasks included
+func RunJobTasks(root *yaml.Node) []*yaml.Node {
+	runs := []*yaml.Node{}
+	var task func(n *yaml.Node)
+	task = func(n *yaml.Node) {
+		if run := n.Get("run_job_task"); run != nil && run.Kind == yaml.MappingNode {
+			runs = append(runs, run)
+		}
+		if nested := n.Get("for_each_task").Get("task"); nested != nil {
+			task(nested)
+		}
+	}
+	if tasks := root.Get("tasks"); tasks != nil && tasks.Kind == yaml.SequenceNode {
+		for _, n := range tasks.Content {
+			task(n)
+		}
+	}
+	return runs
+}
+
+// Replace the `job_name` of the `run_job_task` of converted settings by the id `jobID` gives
+func ResolveJobNames(settings Object, jobID func(name string) (int64, error)) error {
+	var resolve func(task Object) error
+	resolve = func(task Object) error {
+		if run, ok := task.Get("run_job_task").(Object); ok {
+			for i, member := range run {
+				if member.Key != "job_name" {
+					continue
+				}
+				name, _ := member.Value.(string)
+				id, err := jobID(name)
+				if err != nil {
+					return fmt.Errorf("job `%s` of `run_job_task`: %w", name, err)
+				}
+				run[i] = Member{Key: "job_id", Value: id}
+			}
+		}
+		if forEach, ok := task.Get("for_each_task").(Object); ok {
+			if nested, ok := forEach.Get("task").(Object); ok {
+				return resolve(nested)
+			}
+		}
+		return nil
+	}
+	tasks, _ := settings.Get("tasks").([]any)
+	for _, t := range tasks {
+		if object, ok := t.(Object); ok {
+			if err := resolve(object); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Replace the `job_name` of the `run_job_task` of a workflow by the id `jobID` gives, in the tree itself
+// For the tools that take the workflow as YAML, e.g. a bundle
+func ResolveJobNameNodes(root *yaml.Node, jobID func(name string) (int64, error)) error {
+	for _, run := range RunJobTasks(root) {
+		for i := 0; i+1 < len(run.Content); i += 2 {
+			if run.Content[i].Value != "job_name" {
+				continue
+			}
+			name := run.Content[i+1].Value
+			id, err := jobID(name)
+			if err != nil {
+				return fmt.Errorf("job `%s` of `run_job_task`: %w", name, err)
+			}
+			run.Content[i] = renameKey(run.Content[i], "job_id")
+			value := *run.Content[i+1]
+			value.Value, value.Style, value.Tag = strconv.FormatInt(id, 10), yaml.PlainStyle, ""
+			run.Content[i+1] = &value
+		}
+	}
+	return nil
+}
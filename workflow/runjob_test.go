@@ -0,0 +1,77 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveJobNames(t *testing.T) {
+	document := `tasks:
+  - task_key: by_name
+    run_job_task:
+      job_name: nightly
+  - task_key: by_id
+    run_job_task:
+      job_id: 7
+  - task_key: each
+    for_each_task:
+      inputs: "[1, 2]"
+      task:
+        task_key: nested
+        run_job_task:
+          job_name: nightly
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, problems := workflow.Settings(root)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	if err := workflow.ResolveJobNames(settings, func(name string) (int64, error) {
+		if name != "nightly" {
+			return 0, errors.New("unknown job")
+		}
+		return 42, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var output strings.Builder
+	workflow.WriteJSON(&output, settings)
+	if expected, actual := 2, strings.Count(output.String(), `"job_id": 42`); expected != actual || strings.Contains(output.String(), "job_name") {
+		t.Fatalf("Expected: %d resolved names, Actual: %s", expected, output.String())
+	}
+}
+
+func TestRunJobTaskProblems(t *testing.T) {
+	document := `tasks:
+  - task_key: neither
+    run_job_task: {job_parameters: {env: prod}}
+  - task_key: both
+    run_job_task:
+      job_id: 7
+      job_name: nightly
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, problems := workflow.Settings(root)
+
+	actual := []string{}
+	for _, problem := range problems {
+		actual = append(actual, problem.Error())
+	}
+	expected := []string{
+		"3:19: `job_id` or `job_name` is required in `run_job_task`",
+		"7:17: `job_name` and `job_id` can't be both given",
+	}
+	if strings.Join(expected, "\n") != strings.Join(actual, "\n") {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
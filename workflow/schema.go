@@ -0,0 +1,422 @@
+package workflow
+
+type Type int
+
+const (
+	AnyType Type = iota
+	StringType
+	IntType
+	BoolType
+	ObjectType
+	ArrayType
+	MapType
+)
+
+// Definition of a field of the Jobs API
+// Fields of an object are listed in their canonical order, it is the order of the converted output
+type Field struct {
+	Name       string
+	Type       Type
+	Enum       []string
+	Fields     []*Field // known fields of an object
+	Items      *Field   // elements of an array
+	Values     *Field   // values of a map
+	Required   bool
+	Deprecated string // hint shown when the field is used, empty when it is not deprecated
+	Alias      string // name of the field in the API when dbwf-ls accepts another spelling
+}
+
+// Known field of an object, nil when the field is unknown
+func (f *Field) Field(name string) *Field {
+	if f == nil {
+		return nil
+	}
+	for _, field := range f.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// Name of the field in the Jobs API
+func (f *Field) APIName() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// Schema of a field at a path of keys, sequence indexes are skipped
+// Return nil when the path leads outside of the schema
+func Lookup(path []string) *Field {
+	field := Job
+	for _, key := range path {
+		for field != nil && field.Type == ArrayType {
+			field = field.Items
+		}
+		if field == nil {
+			return nil
+		}
+		switch field.Type {
+		case ObjectType:
+			field = field.Field(key)
+		case MapType:
+			field = field.Values
+		default:
+			return nil
+		}
+	}
+	return field
+}
+
+func str(name string) *Field {
+	return &Field{Name: name, Type: StringType}
+}
+
+func integer(name string) *Field {
+	return &Field{Name: name, Type: IntType}
+}
+
+func boolean(name string) *Field {
+	return &Field{Name: name, Type: BoolType}
+}
+
+func anything(name string) *Field {
+	return &Field{Name: name, Type: AnyType}
+}
+
+func enum(name string, values ...string) *Field {
+	return &Field{Name: name, Type: StringType, Enum: values}
+}
+
+func object(name string, fields ...*Field) *Field {
+	return &Field{Name: name, Type: ObjectType, Fields: fields}
+}
+
+func array(name string, items *Field) *Field {
+	return &Field{Name: name, Type: ArrayType, Items: items}
+}
+
+func strs(name string) *Field {
+	return array(name, str(""))
+}
+
+func mapOf(name string, values *Field) *Field {
+	return &Field{Name: name, Type: MapType, Values: values}
+}
+
+func (f *Field) required() *Field {
+	f.Required = true
+	return f
+}
+
+func (f *Field) deprecated(hint string) *Field {
+	f.Deprecated = hint
+	return f
+}
+
+func (f *Field) alias(name string) *Field {
+	f.Alias = name
+	return f
+}
+
+// Copy of a field under another name
+func (f *Field) as(name string) *Field {
+	copied := *f
+	copied.Name = name
+	return &copied
+}
+
+var pauseStatus = enum("pause_status", "PAUSED", "UNPAUSED")
+
+var runIf = enum("run_if", "ALL_SUCCESS", "ALL_DONE", "NONE_FAILED", "AT_LEAST_ONE_SUCCESS", "ALL_FAILED", "AT_LEAST_ONE_FAILED")
+
+var source = enum("source", "WORKSPACE", "GIT")
+
+var emailNotifications = object("email_notifications",
+	strs("on_start"),
+	strs("on_success"),
+	strs("on_failure"),
+	strs("on_duration_warning_threshold_exceeded"),
+	strs("on_streaming_backlog_exceeded"),
+	boolean("no_alert_for_skipped_runs").deprecated("use `notification_settings.no_alert_for_skipped_runs` instead"),
+)
+
+var webhook = object("", str("id").required())
+
+var webhookNotifications = object("webhook_notifications",
+	array("on_start", webhook),
+	array("on_success", webhook),
+	array("on_failure", webhook),
+	array("on_duration_warning_threshold_exceeded", webhook),
+	array("on_streaming_backlog_exceeded", webhook),
+)
+
+var notificationSettings = object("notification_settings",
+	boolean("no_alert_for_skipped_runs"),
+	boolean("no_alert_for_canceled_runs"),
+)
+
+var taskNotificationSettings = object("notification_settings",
+	boolean("no_alert_for_skipped_runs"),
+	boolean("no_alert_for_canceled_runs"),
+	boolean("alert_on_last_attempt"),
+)
+
+var health = object("health",
+	array("rules", object("",
+		enum("metric", "RUN_DURATION_SECONDS", "STREAMING_BACKLOG_BYTES", "STREAMING_BACKLOG_RECORDS", "STREAMING_BACKLOG_SECONDS", "STREAMING_BACKLOG_FILES").required(),
+		enum("op", "GREATER_THAN").required(),
+		integer("value").required(),
+	)),
+)
+
+var Cluster = object("new_cluster",
+	str("cluster_name"),
+	str("spark_version"),
+	str("node_type_id"),
+	str("driver_node_type_id"),
+	integer("num_workers"),
+	object("autoscale",
+		integer("min_workers"),
+		integer("max_workers"),
+	),
+	enum("runtime_engine", "NULL", "STANDARD", "PHOTON"),
+	enum("data_security_mode", "NONE", "SINGLE_USER", "USER_ISOLATION", "LEGACY_TABLE_ACL", "LEGACY_PASSTHROUGH", "LEGACY_SINGLE_USER", "LEGACY_SINGLE_USER_STANDARD", "DATA_SECURITY_MODE_AUTO", "DATA_SECURITY_MODE_STANDARD", "DATA_SECURITY_MODE_DEDICATED"),
+	str("single_user_name"),
+	str("policy_id"),
+	boolean("apply_policy_default_values"),
+	str("instance_pool_id"),
+	str("driver_instance_pool_id"),
+	mapOf("spark_conf", str("")),
+	mapOf("spark_env_vars", str("")),
+	mapOf("custom_tags", str("")),
+	boolean("enable_elastic_disk"),
+	boolean("enable_local_disk_encryption"),
+	integer("autotermination_minutes"),
+	strs("ssh_public_keys"),
+	object("docker_image",
+		str("url").required(),
+		object("basic_auth",
+			str("username"),
+			str("password"),
+		),
+	),
+	array("init_scripts", anything("")),
+	anything("cluster_log_conf"),
+	anything("aws_attributes"),
+	anything("azure_attributes"),
+	anything("gcp_attributes"),
+	anything("workload_type"),
+)
+
+var library = object("",
+	str("jar"),
+	str("egg").deprecated("eggs are not supported from Databricks Runtime 14.0, use `whl` instead"),
+	str("whl"),
+	str("requirements"),
+	object("pypi",
+		str("package").required(),
+		str("repo"),
+	),
+	object("maven",
+		str("coordinates").required(),
+		str("repo"),
+		strs("exclusions"),
+	),
+	object("cran",
+		str("package").required(),
+		str("repo"),
+	),
+)
+
+var Task = object("",
+	str("task_key").required(),
+	str("description"),
+	array("depends_on", object("",
+		str("task_key").required(),
+		str("outcome"),
+	)),
+	runIf,
+	str("existing_cluster_id"),
+	str("job_cluster_key"),
+	Cluster,
+	str("environment_key"),
+	object("notebook_task",
+		str("notebook_path").required(),
+		source,
+		mapOf("base_parameters", str("")),
+		str("warehouse_id"),
+	),
+	object("spark_python_task",
+		str("python_file").required(),
+		strs("parameters"),
+		source,
+	),
+	object("python_wheel_task",
+		str("package_name").required(),
+		str("entry_point").required(),
+		strs("parameters"),
+		mapOf("named_parameters", str("")),
+	),
+	object("spark_jar_task",
+		str("main_class_name"),
+		strs("parameters"),
+		str("jar_uri").deprecated("declare the jar in `libraries` instead"),
+	),
+	object("spark_submit_task",
+		strs("parameters"),
+	),
+	object("sql_task",
+		str("warehouse_id").required(),
+		object("query", str("query_id").required()),
+		object("file",
+			str("path").required(),
+			source,
+		),
+		object("dashboard",
+			str("dashboard_id").required(),
+			str("custom_subject"),
+			boolean("pause_subscriptions"),
+			array("subscriptions", anything("")),
+		),
+		object("alert",
+			str("alert_id").required(),
+			boolean("pause_subscriptions"),
+			array("subscriptions", anything("")),
+		),
+		mapOf("parameters", str("")),
+	),
+	object("dbt_task",
+		strs("commands").required(),
+		str("project_directory"),
+		str("profiles_directory"),
+		str("schema"),
+		str("catalog"),
+		str("warehouse_id"),
+		source,
+	),
+	object("pipeline_task",
+		str("pipeline_id").required(),
+		boolean("full_refresh"),
+	),
+	object("run_job_task",
+		integer("job_id"),
+		str("job_name"), // dbwf-ls only, see `ResolveJobNames`
+		mapOf("job_parameters", str("")),
+	),
+	object("condition_task",
+		enum("op", "EQUAL_TO", "GREATER_THAN", "GREATER_THAN_OR_EQUAL", "LESS_THAN", "LESS_THAN_OR_EQUAL", "NOT_EQUAL").required(),
+		str("left").required(),
+		str("right").required(),
+	),
+	object("for_each_task",
+		str("inputs").required(),
+		integer("concurrency"),
+	),
+	array("libraries", library),
+	integer("timeout_seconds"),
+	integer("max_retries"),
+	integer("min_retry_interval_millis"),
+	boolean("retry_on_timeout"),
+	boolean("disable_auto_optimization"),
+	emailNotifications,
+	webhookNotifications,
+	taskNotificationSettings,
+	health,
+)
+
+var JobCluster = object("",
+	str("job_cluster_key").required(),
+	Cluster.as("new_cluster").required(),
+)
+
+var permission = object("",
+	str("user_name"),
+	str("group_name"),
+	str("service_principal_name"),
+	str("service_principle_name").alias("service_principal_name").deprecated("the API spells it `service_principal_name`"),
+	enum("permission_level", "CAN_MANAGE", "CAN_MANAGE_RUN", "CAN_VIEW", "IS_OWNER").required(),
+)
+
+// The whole `.flow.yaml` document, the settings of a job
+var Job = object("",
+	strs("include"), // dbwf-ls only, see `ResolveIncludes`
+	str("name"),
+	str("description"),
+	mapOf("tags", str("")),
+	emailNotifications,
+	webhookNotifications,
+	notificationSettings,
+	integer("timeout_seconds"),
+	health,
+	object("schedule",
+		str("quartz_cron_expression").required(),
+		str("timezone_id").required(),
+		pauseStatus,
+	),
+	object("trigger",
+		pauseStatus,
+		object("file_arrival",
+			str("url").required(),
+			integer("min_time_between_triggers_seconds"),
+			integer("wait_after_last_change_seconds"),
+		),
+		object("periodic",
+			integer("interval").required(),
+			enum("unit", "HOURS", "DAYS", "WEEKS").required(),
+		),
+		object("table_update",
+			strs("table_names").required(),
+			enum("condition", "ALL_UPDATED", "ANY_UPDATED"),
+			integer("min_time_between_triggers_seconds"),
+			integer("wait_after_last_change_seconds"),
+		),
+	),
+	object("continuous",
+		pauseStatus,
+	),
+	integer("max_concurrent_runs"),
+	array("parameters", object("",
+		str("name").required(),
+		str("default").required(),
+	)),
+	object("run_as",
+		str("user_name"),
+		str("service_principal_name"),
+		str("service_principle_name").alias("service_principal_name").deprecated("the API spells it `service_principal_name`"),
+	),
+	enum("edit_mode", "UI_LOCKED", "EDITABLE"),
+	object("queue",
+		boolean("enabled").required(),
+	),
+	object("git_source",
+		str("git_url").required(),
+		enum("git_provider", "gitHub", "bitbucketCloud", "azureDevOpsServices", "gitHubEnterprise", "bitbucketServer", "gitLab", "gitLabEnterpriseEdition", "awsCodeCommit").required(),
+		str("git_branch"),
+		str("git_tag"),
+		str("git_commit"),
+	),
+	object("deployment",
+		enum("kind", "BUNDLE").required(),
+		str("metadata_file_path"),
+	),
+	array("environments", object("",
+		str("environment_key").required(),
+		object("spec",
+			str("client").required(),
+			strs("dependencies"),
+		),
+	)),
+	enum("format", "SINGLE_TASK", "MULTI_TASK").deprecated("the format of a job is always `MULTI_TASK`"),
+	array("tasks", Task),
+	array("job_clusters", JobCluster),
+	array("access_control_list", permission),
+)
+
+func init() {
+	// A `for_each_task` runs a nested task, the schema refers to itself
+	forEach := Task.Field("for_each_task")
+	forEach.Fields = append(forEach.Fields, Task.as("task").required())
+}
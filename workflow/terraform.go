@@ -0,0 +1,184 @@
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sequences of objects are repeated blocks in the provider, with a singular name
+var terraformBlocks = map[string]string{
+	"tasks":        "task",
+	"job_clusters": "job_cluster",
+	"libraries":    "library",
+	"parameters":   "parameter",
+	"environments": "environment",
+}
+
+// Attributes of `git_source` are not prefixed in the provider
+var terraformGitSource = map[string]string{
+	"git_url":      "url",
+	"git_provider": "provider",
+	"git_branch":   "branch",
+	"git_tag":      "tag",
+	"git_commit":   "commit",
+}
+
+// Render the settings of a job as a `databricks_job` resource of the Databricks Terraform provider
+// Objects are nested blocks and sequences of objects are repeated blocks, e.g. `tasks` is a list of `task`
+// The access control list is a `databricks_permissions` resource of the job
+func Terraform(settings Object, name string) string {
+	var hcl strings.Builder
+	job := Object{}
+	for _, member := range settings {
+		if member.Key != "access_control_list" && member.Key != "format" {
+			job = append(job, member)
+		}
+	}
+
+	fmt.Fprintf(&hcl, "resource \"databricks_job\" %s {\n", terraformString(name))
+	writeTerraformBody(&hcl, job, Job, 1)
+	hcl.WriteString("}\n")
+
+	permissions, _ := settings.Get("access_control_list").([]any)
+	if len(permissions) == 0 {
+		return hcl.String()
+	}
+	fmt.Fprintf(&hcl, "\nresource \"databricks_permissions\" %s {\n", terraformString(name))
+	fmt.Fprintf(&hcl, "  job_id = databricks_job.%s.id\n", name)
+	for _, permission := range permissions {
+		hcl.WriteString("\n")
+		writeTerraformBlock(&hcl, "access_control", permission, Job.Field("access_control_list").Items, 1)
+	}
+	hcl.WriteString("}\n")
+	return hcl.String()
+}
+
+// Attributes first with their `=` aligned, then blocks
+func writeTerraformBody(hcl *strings.Builder, object Object, field *Field, depth int) {
+	indent := strings.Repeat("  ", depth)
+	attributes := [][2]string{}
+	blocks := []Member{}
+	for _, member := range object {
+		child := terraformField(field, member.Key)
+		if isTerraformBlock(member.Value, child) {
+			blocks = append(blocks, member)
+			continue
+		}
+		key := member.Key
+		if renamed, found := terraformGitSource[key]; found && field == Job.Field("git_source") {
+			key = renamed
+		}
+		attributes = append(attributes, [2]string{key, terraformValue(member.Value, depth)})
+	}
+
+	width := 0
+	for _, attribute := range attributes {
+		width = max(width, len(attribute[0]))
+	}
+	for _, attribute := range attributes {
+		fmt.Fprintf(hcl, "%s%-*s = %s\n", indent, width, attribute[0], attribute[1])
+	}
+
+	for i, member := range blocks {
+		// Top-level blocks are separated by an empty line, like `terraform fmt` leaves them
+		if depth == 1 && (i > 0 || len(attributes) > 0) {
+			hcl.WriteString("\n")
+		}
+		child := terraformField(field, member.Key)
+		if items, ok := member.Value.([]any); ok {
+			name := member.Key
+			if block, found := terraformBlocks[name]; found {
+				name = block
+			}
+			for j, item := range items {
+				if depth == 1 && j > 0 {
+					hcl.WriteString("\n")
+				}
+				writeTerraformBlock(hcl, name, item, child.Items, depth)
+			}
+			continue
+		}
+		writeTerraformBlock(hcl, member.Key, member.Value, child, depth)
+	}
+}
+
+func writeTerraformBlock(hcl *strings.Builder, name string, value any, field *Field, depth int) {
+	indent := strings.Repeat("  ", depth)
+	object, _ := value.(Object)
+	if len(object) == 0 {
+		fmt.Fprintf(hcl, "%s%s {}\n", indent, name)
+		return
+	}
+	fmt.Fprintf(hcl, "%s%s {\n", indent, name)
+	writeTerraformBody(hcl, object, field, depth+1)
+	fmt.Fprintf(hcl, "%s}\n", indent)
+}
+
+// Objects of the schema and objects of free-form fields are blocks, maps are attributes
+func isTerraformBlock(value any, field *Field) bool {
+	switch value := value.(type) {
+	case Object:
+		return field.Type != MapType
+	case []any:
+		return len(value) > 0 && !slices.ContainsFunc(value, func(item any) bool {
+			_, ok := item.(Object)
+			return !ok
+		})
+	}
+	return false
+}
+
+// Schema of a field, free-form when the schema doesn't know it
+func terraformField(field *Field, key string) *Field {
+	if field != nil {
+		switch field.Type {
+		case ObjectType:
+			if known := field.Field(key); known != nil {
+				return known
+			}
+		case MapType:
+			return field.Values
+		case ArrayType:
+			return field.Items
+		}
+	}
+	return &Field{Type: AnyType}
+}
+
+func terraformValue(value any, depth int) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return terraformString(value)
+	case Object:
+		if len(value) == 0 {
+			return "{}"
+		}
+		// Map attributes are written one key per line, keys quoted and aligned
+		indent := strings.Repeat("  ", depth+1)
+		width := 0
+		for _, member := range value {
+			width = max(width, len(terraformString(member.Key)))
+		}
+		lines := []string{"{"}
+		for _, member := range value {
+			lines = append(lines, fmt.Sprintf("%s%-*s = %s", indent, width, terraformString(member.Key), terraformValue(member.Value, depth+1)))
+		}
+		return strings.Join(append(lines, strings.Repeat("  ", depth)+"}"), "\n")
+	case []any:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, terraformValue(item, depth))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
+
+// Quoted HCL string, interpolation sequences are escaped so values are taken literally
+func terraformString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + replacer.Replace(value) + `"`
+}
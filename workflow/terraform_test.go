@@ -0,0 +1,75 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"dbwf-ls/yaml"
+	"testing"
+)
+
+func TestTerraform(t *testing.T) {
+	document := `name: "Report ${env}"
+tags:
+  team: "jobs"
+max_concurrent_runs: 1
+git_source:
+  git_url: "https://github.com/org/repo"
+  git_provider: gitHub
+tasks:
+  - task_key: "report"
+    depends_on:
+      - task_key: "ingest"
+    libraries:
+      - whl: "report.whl"
+  - task_key: "ingest"
+access_control_list:
+  - group_name: "users"
+    permission_level: CAN_VIEW
+`
+	expected := `resource "databricks_job" "report" {
+  name                = "Report $${env}"
+  tags                = {
+    "team" = "jobs"
+  }
+  max_concurrent_runs = 1
+
+  git_source {
+    url      = "https://github.com/org/repo"
+    provider = "gitHub"
+  }
+
+  task {
+    task_key = "report"
+    depends_on {
+      task_key = "ingest"
+    }
+    library {
+      whl = "report.whl"
+    }
+  }
+
+  task {
+    task_key = "ingest"
+  }
+}
+
+resource "databricks_permissions" "report" {
+  job_id = databricks_job.report.id
+
+  access_control {
+    group_name       = "users"
+    permission_level = "CAN_VIEW"
+  }
+}
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, problems := workflow.Settings(root)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	if actual := workflow.Terraform(settings, "report"); expected != actual {
+		t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+	}
+}
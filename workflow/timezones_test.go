@@ -0,0 +1,25 @@
+package workflow_test
+
+import (
+	"dbwf-ls/workflow"
+	"testing"
+)
+
+func TestTimeZones(t *testing.T) {
+	for _, zone := range workflow.TimeZones {
+		if _, err := workflow.LoadTimeZone(zone); err != nil {
+			t.Fatalf("%s: %s", zone, err)
+		}
+	}
+
+	tests := map[string]string{
+		"Europe/Helsiki":   "Europe/Helsinki",
+		"america/new_york": "America/New_York",
+		"Mars/Olympus":     "",
+	}
+	for misspelled, expected := range tests {
+		if actual := workflow.SuggestTimeZone(misspelled); expected != actual {
+			t.Fatalf("Expected: %s, Actual: %s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Write a node tree back as YAML, with 2 spaces of indentation and the comments of the nodes
+// Sequences are indented under their key and scalars keep their style
+func Encode(root *Node) string {
+	e := &encoder{}
+	switch {
+	case isBlock(root):
+		e.block(root, 0)
+	case root.Kind == ScalarNode && root.IsNull() && root.Tag == "" && root.Anchor == "":
+		e.comments(root.FootComment, 0)
+	default:
+		e.comments(root.HeadComment, 0)
+		e.line(0, e.inline(root, 0), root.LineComment)
+		e.comments(root.FootComment, 0)
+	}
+
+	return e.String()
+}
+
+type encoder struct {
+	strings.Builder
+}
+
+// Whether a node is written as an indented block below its key
+func isBlock(n *Node) bool {
+	return (n.Kind == MappingNode || n.Kind == SequenceNode) && n.Style != FlowStyle && len(n.Content) > 0
+}
+
+func (e *encoder) line(indent int, text, comment string) {
+	e.WriteString(strings.Repeat(" ", indent))
+	e.WriteString(text)
+	if comment != "" {
+		if text != "" {
+			e.WriteByte(' ')
+		}
+		e.WriteString(comment)
+	}
+	e.WriteByte('\n')
+}
+
+func (e *encoder) comments(text string, indent int) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		e.line(indent, "", line)
+	}
+}
+
+// Anchor and tag of a node, with a trailing space
+func properties(n *Node) string {
+	text := ""
+	if n.Anchor != "" {
+		text += "&" + n.Anchor + " "
+	}
+	if n.Tag != "" {
+		text += n.Tag + " "
+	}
+	return text
+}
+
+func (e *encoder) block(n *Node, indent int) {
+	if n.Kind == MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			e.pair(n.Content[i], n.Content[i+1], indent, "")
+		}
+	} else {
+		for _, item := range n.Content {
+			e.item(item, indent)
+		}
+	}
+	e.comments(n.FootComment, indent)
+}
+
+// `key: value` at a given indentation, `prefix` replaces the indentation of the first line
+func (e *encoder) pair(key, value *Node, indent int, prefix string) {
+	if prefix == "" {
+		if key.BlankLine {
+			e.WriteByte('\n')
+		}
+		e.comments(key.HeadComment, indent)
+		prefix = strings.Repeat(" ", indent)
+	}
+	text := prefix + e.scalar(key, indent) + ":"
+
+	if isBlock(value) {
+		if props := strings.TrimSpace(properties(value)); props != "" {
+			text += " " + props
+		}
+		comment := key.LineComment
+		if comment == "" {
+			comment = value.LineComment
+		}
+		e.line(0, text, comment)
+		e.comments(value.HeadComment, indent+2)
+		e.block(value, indent+2)
+		return
+	}
+
+	if !value.IsNull() || value.Tag != "" || value.Anchor != "" {
+		text += " " + strings.TrimSuffix(properties(value)+e.inline(value, indent+2), " ")
+	}
+	comment := value.LineComment
+	if comment == "" {
+		comment = key.LineComment
+	}
+	if isBlockScalar(value) {
+		e.line(0, text, comment)
+		e.blockScalar(value, indent+2)
+		return
+	}
+	e.line(0, text, comment)
+}
+
+// `- item` at a given indentation
+func (e *encoder) item(n *Node, indent int) {
+	if n.BlankLine {
+		e.WriteByte('\n')
+	}
+	head := n.HeadComment
+	if n.Kind == MappingNode && isBlock(n) && n.Anchor == "" && n.Tag == "" && n.LineComment == "" {
+		// The first key shares the line of the `-`, its comments go above
+		key := n.Content[0]
+		head = strings.TrimPrefix(head+"\n"+key.HeadComment, "\n")
+		e.comments(strings.TrimSuffix(head, "\n"), indent)
+		e.pair(key, n.Content[1], indent+2, strings.Repeat(" ", indent)+"- ")
+		for i := 2; i+1 < len(n.Content); i += 2 {
+			e.pair(n.Content[i], n.Content[i+1], indent+2, "")
+		}
+		e.comments(n.FootComment, indent+2)
+		return
+	}
+
+	e.comments(head, indent)
+	if isBlock(n) {
+		e.line(indent, "-"+strings.TrimSuffix(" "+properties(n), " "), n.LineComment)
+		e.block(n, indent+2)
+		return
+	}
+
+	text := "-"
+	if !n.IsNull() || n.Tag != "" || n.Anchor != "" {
+		text += " " + strings.TrimSuffix(properties(n)+e.inline(n, indent+2), " ")
+	}
+	e.line(indent, text, n.LineComment)
+	if isBlockScalar(n) {
+		e.blockScalar(n, indent+2)
+	}
+}
+
+// A node written on a single line, block scalars only write their header
+func (e *encoder) inline(n *Node, indent int) string {
+	switch n.Kind {
+	case AliasNode:
+		return "*" + n.Value
+	case MappingNode:
+		pairs := []string{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			pair := e.scalar(key, indent) + ":"
+			if !value.IsNull() || value.Tag != "" || value.Anchor != "" {
+				pair += " " + properties(value) + e.inline(value, indent)
+			}
+			pairs = append(pairs, pair)
+		}
+		if len(pairs) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	case SequenceNode:
+		items := []string{}
+		for _, item := range n.Content {
+			items = append(items, properties(item)+e.inline(item, indent))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return e.scalar(n, indent)
+}
+
+// Whether a scalar is written as a literal or folded block, empty ones have no lines to write and are quoted
+func isBlockScalar(n *Node) bool {
+	return (n.Style == LiteralStyle || n.Style == FoldedStyle) && strings.Trim(n.Value, "\n") != ""
+}
+
+func (e *encoder) scalar(n *Node, indent int) string {
+	switch {
+	case isBlockScalar(n):
+		header := "|"
+		if n.Style == FoldedStyle {
+			header = ">"
+		}
+		if strings.HasPrefix(n.Value, " ") || strings.HasPrefix(n.Value, "\n") {
+			header += "2"
+		}
+		switch {
+		case !strings.HasSuffix(n.Value, "\n"):
+			header += "-"
+		case strings.HasSuffix(n.Value, "\n\n"):
+			header += "+"
+		}
+		return header
+	case n.Style == SingleQuotedStyle:
+		if !strings.ContainsAny(n.Value, "\n\t") {
+			return "'" + strings.ReplaceAll(n.Value, "'", "''") + "'"
+		}
+	case n.Style == PlainStyle:
+		if !strings.ContainsAny(n.Value, "\n\t") {
+			return n.Value
+		}
+	}
+	return Quote(n.Value)
+}
+
+// Content lines of a literal or folded block scalar
+func (e *encoder) blockScalar(n *Node, indent int) {
+	value := strings.TrimRight(n.Value, "\n")
+	lines := strings.Split(value, "\n")
+	if n.Style == FoldedStyle {
+		lines = foldedLines(lines)
+	}
+	for _, line := range lines {
+		if line == "" {
+			e.WriteByte('\n')
+			continue
+		}
+		e.line(indent, line, "")
+	}
+	for i := len(n.Value) - len(value); i > 1; i-- {
+		e.WriteByte('\n')
+	}
+}
+
+// Lines of a folded block scalar from the lines of its value, the parser folds them back into the value
+// A line break between two lines becomes an empty line, except next to a more indented line that keeps its line breaks
+func foldedLines(lines []string) []string {
+	folded, previous := []string{}, ""
+	for _, line := range lines {
+		if line == "" {
+			folded = append(folded, line)
+			continue
+		}
+		indented := func(line string) bool { return line[0] == ' ' || line[0] == '\t' }
+		if previous != "" && !indented(previous) && !indented(line) {
+			folded = append(folded, "")
+		}
+		folded = append(folded, line)
+		previous = line
+	}
+	return folded
+}
+
+var quoteEscapes = map[rune]string{
+	'\\': `\\`, '"': `\"`, '\n': `\n`, '\t': `\t`, '\r': `\r`, '\x00': `\0`, '\a': `\a`,
+	'\b': `\b`, '\v': `\v`, '\f': `\f`, '\x1b': `\e`, '\u0085': `\N`, '\u2028': `\L`, '\u2029': `\P`,
+}
+
+// Double quoted scalar
+func Quote(value string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, r := range value {
+		if escaped, found := quoteEscapes[r]; found {
+			quoted.WriteString(escaped)
+		} else if r < 0x20 || r == 0x7f {
+			quoted.WriteString(fmt.Sprintf(`\x%02x`, r))
+		} else {
+			quoted.WriteRune(r)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
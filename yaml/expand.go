@@ -0,0 +1,110 @@
+package yaml
+
+import "strconv"
+
+// Copy of a tree where aliases are replaced by the node of their anchor and merge keys `<<` are merged into their mapping
+// An alias refers to the last anchor of its name before it. Keys written in a mapping win over merged ones,
+// and the first merged mapping over the next ones, like YAML 1.1 merge keys.
+// Copies keep the positions of the nodes they copy, so a copied alias points at its anchor. Anchors are dropped
+func Expand(root *Node) (*Node, error) {
+	e := expander{anchors: map[string]*Node{}, sizes: map[*Node]int{}}
+	return e.expand(root)
+}
+
+// Nodes an expanded tree may count, the copies of an anchor share their children but walking the tree visits each of them
+// A few aliases of aliases of a big anchor are enough to make a document way bigger than any workflow, e.g. billion laughs
+const maxExpandedNodes = 100_000
+
+type expander struct {
+	anchors map[string]*Node // expanded anchored nodes by name as the document defines them so far, nil while expanding
+	sizes   map[*Node]int    // nodes an expanded node counts with its children, as walking the tree visits them
+}
+
+// Record the size of an expanded node, the expansion stops when it is too big
+func (e *expander) sized(copied *Node, size int, at *Node) (*Node, error) {
+	if size > maxExpandedNodes {
+		return nil, &Error{Line: at.Line, Column: at.Column, Message: "aliases expand to too many nodes, more than " + strconv.Itoa(maxExpandedNodes)}
+	}
+	e.sizes[copied] = size
+	return copied, nil
+}
+
+func (e *expander) expand(n *Node) (*Node, error) {
+	if n.Kind == AliasNode {
+		anchored, found := e.anchors[n.Value]
+		if !found {
+			return nil, &Error{Line: n.Line, Column: n.Column, Message: "unknown anchor `" + n.Value + "`"}
+		}
+		if anchored == nil {
+			return nil, &Error{Line: n.Line, Column: n.Column, Message: "alias `*" + n.Value + "` is inside its own anchor"}
+		}
+		copied := *anchored
+		copied.HeadComment, copied.LineComment = n.HeadComment, n.LineComment
+		return e.sized(&copied, e.sizes[anchored], n)
+	}
+
+	if n.Anchor == "" {
+		return e.copy(n)
+	}
+	e.anchors[n.Anchor] = nil
+	copied, err := e.copy(n)
+	e.anchors[n.Anchor] = copied
+	return copied, err
+}
+
+// Copy of a node with its children expanded
+func (e *expander) copy(n *Node) (*Node, error) {
+	copied := *n
+	copied.Anchor = ""
+	copied.Content = nil
+	size := 1
+	if n.Kind != MappingNode {
+		for _, child := range n.Content {
+			expanded, err := e.expand(child)
+			if err != nil {
+				return nil, err
+			}
+			copied.Content = append(copied.Content, expanded)
+			size += e.sizes[expanded]
+		}
+		return e.sized(&copied, size, n)
+	}
+
+	merged := []*Node{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		expandedValue, err := e.expand(value)
+		if err != nil {
+			return nil, err
+		}
+		if key.Kind == ScalarNode && key.Value == "<<" && key.Style == PlainStyle {
+			sources := []*Node{expandedValue}
+			if expandedValue.Kind == SequenceNode {
+				sources = expandedValue.Content
+			}
+			for _, source := range sources {
+				if source.Kind != MappingNode {
+					return nil, &Error{Line: value.Line, Column: value.Column, Message: "`<<` merges mappings, found " + source.Describe()}
+				}
+				merged = append(merged, source)
+			}
+			continue
+		}
+		expandedKey, err := e.expand(key)
+		if err != nil {
+			return nil, err
+		}
+		copied.Content = append(copied.Content, expandedKey, expandedValue)
+		size += e.sizes[expandedKey] + e.sizes[expandedValue]
+	}
+
+	for _, source := range merged {
+		for i := 0; i+1 < len(source.Content); i += 2 {
+			if copied.Get(source.Content[i].Value) == nil {
+				copied.Content = append(copied.Content, source.Content[i], source.Content[i+1])
+				size += e.sizes[source.Content[i]] + e.sizes[source.Content[i+1]]
+			}
+		}
+	}
+	return e.sized(&copied, size, n)
+}
@@ -0,0 +1,96 @@
+package yaml_test
+
+import (
+	"dbwf-ls/yaml"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	document := `defaults: &defaults
+  spark_version: 15.4.x-scala2.12
+  num_workers: 2
+job_clusters:
+  - job_cluster_key: small
+    new_cluster: *defaults
+  - job_cluster_key: big
+    new_cluster:
+      <<: *defaults
+      num_workers: &workers 8
+  - job_cluster_key: other
+    new_cluster: {<<: [{node_type_id: i3.xlarge}, *defaults], num_workers: *workers}
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expanded, err := yaml.Expand(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `defaults:
+  spark_version: 15.4.x-scala2.12
+  num_workers: 2
+job_clusters:
+  - job_cluster_key: small
+    new_cluster:
+      spark_version: 15.4.x-scala2.12
+      num_workers: 2
+  - job_cluster_key: big
+    new_cluster:
+      num_workers: 8
+      spark_version: 15.4.x-scala2.12
+  - job_cluster_key: other
+    new_cluster: { num_workers: 8, node_type_id: i3.xlarge, spark_version: 15.4.x-scala2.12 }
+`
+	if actual := yaml.Encode(expanded); expected != actual {
+		t.Fatalf("Expanded, expected: %s, got: %s", expected, actual)
+	}
+	if small := expanded.Get("job_clusters").Content[0].Get("new_cluster"); small.Line != 1 {
+		t.Fatalf("Alias position, expected: the anchored node at line 1, got: line %d", small.Line)
+	}
+	if anchor := root.Get("defaults"); anchor.AnchorLine != 0 || anchor.AnchorColumn != 10 {
+		t.Fatalf("Anchor position, expected: 0:10, got: %d:%d", anchor.AnchorLine, anchor.AnchorColumn)
+	}
+
+	for _, broken := range []string{"a: *missing\n", "a: &a [1, *a]\n", "a: &a 1\nb: {<<: *a}\n"} {
+		root, err := yaml.Parse(broken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := yaml.Expand(root); err == nil {
+			t.Fatalf("Expand %q, expected: an error, got: none", broken)
+		}
+	}
+}
+
+func TestExpandBillionLaughs(t *testing.T) {
+	document := `a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d, *d]
+f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e, *e]
+g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f, *f]
+h: &h [*g, *g, *g, *g, *g, *g, *g, *g, *g, *g]
+i: &i [*h, *h, *h, *h, *h, *h, *h, *h, *h, *h]
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = yaml.Expand(root)
+	if expected := "5:7: aliases expand to too many nodes, more than 100000"; err == nil || expected != err.Error() {
+		t.Fatalf("Expand, expected: %s, got: %v", expected, err)
+	}
+
+	// Aliases within the limit expand as usual
+	root, err = yaml.Parse(document[:strings.Index(document, "e:")])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := yaml.Expand(root); err != nil {
+		t.Fatalf("Expand, expected: no error, got: %s", err)
+	}
+}
@@ -0,0 +1,123 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Kind int
+
+const (
+	ScalarNode Kind = iota + 1
+	MappingNode
+	SequenceNode
+	AliasNode
+)
+
+type Style int
+
+const (
+	PlainStyle Style = iota
+	SingleQuotedStyle
+	DoubleQuotedStyle
+	LiteralStyle
+	FoldedStyle
+	FlowStyle
+)
+
+// A node of the parsed document
+// Mappings keep their keys and values interleaved in `Content` (key, value, key, value...)
+// Sequences keep their items in `Content`
+// Lines and columns are 0-based, the end is exclusive
+type Node struct {
+	Kind    Kind
+	Style   Style
+	Tag     string
+	Anchor  string
+	Value   string // scalar value, or the anchor name of an alias
+	Content []*Node
+
+	Line, Column       int
+	EndLine, EndColumn int
+	AnchorLine         int // position of the `&` of the anchor, the node may start after it or on the next line
+	AnchorColumn       int
+
+	HeadComment  string // comment lines right above a key or a sequence item
+	LineComment  string // comment at the end of the line
+	FootComment  string // comment lines closing a mapping or a sequence
+	InnerComment string // comments inside a flow collection, nested ones included, a single line has no room for them
+
+	BlankLine bool // empty line written above a key or a sequence item and its head comment, not set by the parser
+}
+
+// Syntax error with its position in the document
+type Error struct {
+	Line, Column int
+	Message      string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line+1, e.Column+1, e.Message)
+}
+
+// Whether the node is an empty or null scalar
+func (n *Node) IsNull() bool {
+	if n == nil {
+		return true
+	}
+	if n.Kind != ScalarNode || n.Style != PlainStyle || n.Tag != "" {
+		return false
+	}
+	switch n.Value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// Value of a key in a mapping, nil when the key or the mapping is missing
+func (n *Node) Get(key string) *Node {
+	if n == nil || n.Kind != MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Key node of a key in a mapping, nil when the key or the mapping is missing
+func (n *Node) Key(key string) *Node {
+	if n == nil || n.Kind != MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i]
+		}
+	}
+	return nil
+}
+
+// Kind of the node as written in error messages
+func (n *Node) Describe() string {
+	switch n.Kind {
+	case MappingNode:
+		return "mapping"
+	case SequenceNode:
+		return "sequence"
+	case AliasNode:
+		return "alias"
+	}
+	if n.IsNull() {
+		return "empty value"
+	}
+	return "scalar"
+}
+
+// Join comment lines, empty ones included, they are blank lines of the comment
+func joinComments(comments []string) string {
+	return strings.Join(comments, "\n")
+}
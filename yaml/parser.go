@@ -0,0 +1,957 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parse a single YAML document
+// It covers what workflow files use: block and flow collections, plain, quoted and block scalars,
+// comments, anchors, aliases and tags. Comments are kept on the nodes so the tree can be written back
+func Parse(src string) (*Node, error) {
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	p := &parser{lines: lines}
+
+	return p.parseDocument()
+}
+
+type comment struct {
+	text   string
+	column int
+}
+
+type parser struct {
+	lines    []string
+	row, col int
+	pending  []comment
+	err      *Error // sticky error found while skipping lines
+	ended    bool   // a `...` marker closed the document
+
+	flowComments []string // comments inside the flow collections being parsed
+	flowDepth    int
+}
+
+func (p *parser) errorf(format string, args ...any) *Error {
+	return &Error{Line: p.row, Column: p.col, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseDocument() (*Node, error) {
+	// Directives and the document start marker
+	for p.row < len(p.lines) {
+		line := strings.TrimSpace(p.lines[p.row])
+		if strings.HasPrefix(p.lines[p.row], "%") || line == "---" {
+			p.row++
+			continue
+		}
+		break
+	}
+
+	if !p.nextContent() {
+		if p.err != nil {
+			return nil, p.err
+		}
+		root := &Node{Kind: ScalarNode}
+		root.FootComment = p.takeComments()
+		return root, nil
+	}
+
+	root, err := p.parseNode(-1, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if p.nextContent() {
+		return nil, p.errorf("unexpected content at the end of the document")
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.pending != nil {
+		root.FootComment = strings.TrimPrefix(root.FootComment+"\n"+p.takeComments(), "\n")
+	}
+
+	return root, nil
+}
+
+// Move the cursor to the next token, collecting whole line comments on the way
+// Return false at the end of the document
+func (p *parser) nextContent() bool {
+	for p.row < len(p.lines) {
+		line := p.lines[p.row]
+		start := p.col == 0
+		p.skipSpaces()
+		if p.col < len(line) {
+			if line[p.col] != '#' {
+				if start && strings.ContainsRune(line[:p.col], '\t') {
+					p.err = p.errorf("tabs are not allowed for indentation")
+					return false
+				}
+				if start && line[p.col] == '.' && documentEnd(line) {
+					// Only comments may follow the end of the document
+					p.ended = true
+					p.row++
+					p.col = 0
+					continue
+				}
+				if start && (p.ended || documentMarker(line)) {
+					p.err = p.errorf("multiple documents are not supported")
+					return false
+				}
+				return true
+			}
+			if start {
+				p.pending = append(p.pending, comment{text: strings.TrimRight(line[p.col:], " \t"), column: p.col})
+			}
+		}
+		p.row++
+		p.col = 0
+	}
+	return false
+}
+
+// Whether a line starts a new document with `---`
+func documentMarker(line string) bool {
+	return line == "---" || strings.HasPrefix(line, "--- ")
+}
+
+// Whether a line ends the document with `...`, an optional comment may follow
+func documentEnd(line string) bool {
+	rest, found := strings.CutPrefix(line, "...")
+	if !found || strings.TrimSpace(rest) == "" {
+		return found
+	}
+	return (rest[0] == ' ' || rest[0] == '\t') && strings.TrimLeft(rest, " \t")[0] == '#'
+}
+
+// Pending comments as a head comment
+func (p *parser) takeComments() string {
+	texts := []string{}
+	for _, c := range p.pending {
+		texts = append(texts, c.text)
+	}
+	p.pending = nil
+	return joinComments(texts)
+}
+
+// Pending comments indented at least as deep as a collection that just ended
+func (p *parser) takeFootComments(column int) string {
+	texts := []string{}
+	i := 0
+	for ; i < len(p.pending) && p.pending[i].column >= column; i++ {
+		texts = append(texts, p.pending[i].text)
+	}
+	p.pending = p.pending[i:]
+	if len(p.pending) == 0 {
+		p.pending = nil
+	}
+	return joinComments(texts)
+}
+
+func (p *parser) line() string {
+	return p.lines[p.row]
+}
+
+func (p *parser) eol() bool {
+	return p.col >= len(p.lines[p.row])
+}
+
+func (p *parser) peek() byte {
+	if p.eol() {
+		return 0
+	}
+	return p.lines[p.row][p.col]
+}
+
+func (p *parser) skipSpaces() {
+	line := p.lines[p.row]
+	for p.col < len(line) && (line[p.col] == ' ' || line[p.col] == '\t') {
+		p.col++
+	}
+}
+
+// Comment at the end of the current line, anything else than a comment is an error
+func (p *parser) lineComment() (string, error) {
+	p.skipSpaces()
+	if p.eol() {
+		return "", nil
+	}
+	if p.peek() != '#' {
+		return "", p.errorf("unexpected content %q after value", strings.TrimSpace(p.line()[p.col:]))
+	}
+	text := strings.TrimRight(p.line()[p.col:], " \t")
+	p.col = len(p.line())
+	return text, nil
+}
+
+func (p *parser) isSeqIndicator() bool {
+	line := p.line()
+	return p.peek() == '-' && (p.col+1 == len(line) || line[p.col+1] == ' ' || line[p.col+1] == '\t')
+}
+
+// Index of the `:` making the current line a `key: value` pair, -1 if it is not one
+func (p *parser) plainKeyIndicator() int {
+	line := p.line()
+	if strings.ContainsRune("#&*!|>%@`[]{},?", rune(p.peek())) || p.isSeqIndicator() {
+		return -1
+	}
+	for i := p.col; i < len(line); i++ {
+		switch line[i] {
+		case '#':
+			if i > 0 && (line[i-1] == ' ' || line[i-1] == '\t') {
+				return -1
+			}
+		case ':':
+			if i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Whether a quoted scalar at the cursor is the key of a `key: value` pair
+func (p *parser) isQuotedKey() bool {
+	row, col := p.row, p.col
+	defer func() { p.row, p.col = row, col }()
+
+	if _, err := p.scanQuoted(); err != nil || p.row != row {
+		return false
+	}
+	p.skipSpaces()
+	line := p.line()
+	return p.peek() == ':' && (p.col+1 == len(line) || line[p.col+1] == ' ' || line[p.col+1] == '\t')
+}
+
+// Parse the node starting at the cursor
+// `parent` is the indentation of the enclosing block collection, nested blocks must be deeper
+// `inline` is set when the node follows a key on the same line, where a new mapping cannot start
+// `mapValue` is set for values of a mapping, where a sequence may sit at the indentation of the key
+func (p *parser) parseNode(parent int, inline, mapValue bool) (*Node, error) {
+	anchor, tag := "", ""
+	anchorRow, anchorCol := 0, 0
+	for p.peek() == '&' || p.peek() == '!' {
+		if p.peek() == '&' {
+			anchorRow, anchorCol = p.row, p.col
+			anchor = p.readName()[1:]
+			if anchor == "" {
+				return nil, &Error{Line: anchorRow, Column: anchorCol, Message: "anchor without a name"}
+			}
+		} else {
+			tag = p.readName()
+		}
+		p.skipSpaces()
+	}
+
+	var node *Node
+	var err error
+	if (anchor != "" || tag != "") && (p.eol() || p.peek() == '#') {
+		// The content of the node starts on the next line
+		row, col := p.row, p.col
+		var text string
+		text, err = p.lineComment()
+		if err == nil {
+			node, err = p.parseBlockValue(parent, mapValue, row, col)
+		}
+		if err == nil && text != "" && node.LineComment == "" {
+			node.LineComment = text
+		}
+	} else {
+		node, err = p.parseContent(parent, inline)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if anchor != "" {
+		if node.Anchor != "" {
+			return nil, &Error{Line: node.Line, Column: node.Column, Message: "a node cannot have two anchors"}
+		}
+		node.Anchor = anchor
+		node.AnchorLine, node.AnchorColumn = anchorRow, anchorCol
+	}
+	if tag != "" {
+		if node.Tag != "" {
+			return nil, &Error{Line: node.Line, Column: node.Column, Message: "a node cannot have two tags"}
+		}
+		node.Tag = tag
+	}
+
+	return node, nil
+}
+
+// Value written on the lines below its key or its `-`, or an empty value at the given position
+func (p *parser) parseBlockValue(parent int, mapValue bool, row, col int) (*Node, error) {
+	if p.nextContent() && (p.col > parent || (mapValue && p.col == parent && p.isSeqIndicator())) {
+		return p.parseNode(parent, false, mapValue)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Node{Kind: ScalarNode, Line: row, Column: col, EndLine: row, EndColumn: col}, nil
+}
+
+func (p *parser) parseContent(parent int, inline bool) (*Node, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		return p.parseAlias()
+	case c == '|' || c == '>':
+		return p.parseBlockScalar(parent)
+	case c == '[' || c == '{':
+		node, err := p.parseFlow()
+		if err != nil {
+			return nil, err
+		}
+		node.LineComment, err = p.lineComment()
+		return node, err
+	case p.isSeqIndicator():
+		if inline {
+			return nil, p.errorf("a sequence cannot start on the line of its key")
+		}
+		return p.parseSequence()
+	case c == '"' || c == '\'':
+		if !inline && p.isQuotedKey() {
+			return p.parseMapping()
+		}
+		node, err := p.scanQuoted()
+		if err != nil {
+			return nil, err
+		}
+		node.LineComment, err = p.lineComment()
+		return node, err
+	case c == '?':
+		return nil, p.errorf("complex keys are not supported")
+	}
+
+	if !inline && p.plainKeyIndicator() >= 0 {
+		return p.parseMapping()
+	}
+	return p.parsePlain(parent)
+}
+
+func (p *parser) parseMapping() (*Node, error) {
+	column := p.col
+	node := &Node{Kind: MappingNode, Line: p.row, Column: column}
+
+	for {
+		head := p.takeComments()
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		key.HeadComment = head
+		colon := p.col
+
+		var value *Node
+		p.skipSpaces()
+		if !p.eol() && p.peek() != '#' {
+			value, err = p.parseNode(column, true, true)
+		} else {
+			key.LineComment, err = p.lineComment()
+			if err == nil {
+				value, err = p.parseBlockValue(column, true, key.Line, colon)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, key, value)
+		node.EndLine, node.EndColumn = value.EndLine, value.EndColumn
+
+		if !p.nextContent() || p.col < column {
+			break
+		}
+		if p.col > column {
+			return nil, p.errorf("unexpected indentation, expected %d spaces", column)
+		}
+		if p.isSeqIndicator() {
+			return nil, p.errorf("expected a key, found a sequence item")
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.row >= len(p.lines) || p.col < column {
+		node.FootComment = p.takeFootComments(column)
+	}
+
+	return node, nil
+}
+
+// Key of a `key: value` pair, the cursor is left right after the `:`
+func (p *parser) parseKey() (*Node, error) {
+	var key *Node
+	switch p.peek() {
+	case '"', '\'':
+		var err error
+		key, err = p.scanQuoted()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		indicator := p.plainKeyIndicator()
+		if indicator < 0 {
+			return nil, p.errorf("expected a key, found %q", strings.TrimSpace(p.line()[p.col:]))
+		}
+		value := strings.TrimRight(p.line()[p.col:indicator], " \t")
+		key = &Node{
+			Kind:      ScalarNode,
+			Value:     value,
+			Line:      p.row,
+			Column:    p.col,
+			EndLine:   p.row,
+			EndColumn: p.col + len(value),
+		}
+		p.col = indicator
+	}
+
+	p.skipSpaces()
+	if p.peek() != ':' {
+		return nil, p.errorf("expected `:` after key %q", key.Value)
+	}
+	p.col++
+
+	return key, nil
+}
+
+func (p *parser) parseSequence() (*Node, error) {
+	column := p.col
+	node := &Node{Kind: SequenceNode, Line: p.row, Column: column}
+
+	for {
+		head := p.takeComments()
+		row := p.row
+		p.col++
+		p.skipSpaces()
+
+		var item *Node
+		var err error
+		if !p.eol() && p.peek() != '#' {
+			item, err = p.parseNode(column, false, false)
+		} else {
+			var text string
+			text, err = p.lineComment()
+			if err == nil {
+				item, err = p.parseBlockValue(column, false, row, column+1)
+			}
+			if err == nil && text != "" && item.LineComment == "" {
+				item.LineComment = text
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		item.HeadComment = strings.TrimPrefix(head+"\n"+item.HeadComment, "\n")
+		item.HeadComment = strings.TrimSuffix(item.HeadComment, "\n")
+		node.Content = append(node.Content, item)
+		node.EndLine, node.EndColumn = item.EndLine, item.EndColumn
+
+		if !p.nextContent() || p.col < column {
+			break
+		}
+		if p.col > column {
+			return nil, p.errorf("unexpected indentation, expected %d spaces", column)
+		}
+		if !p.isSeqIndicator() {
+			break
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.row >= len(p.lines) || p.col < column {
+		node.FootComment = p.takeFootComments(column)
+	}
+
+	return node, nil
+}
+
+// Anchor, alias or tag name, starting with its indicator
+func (p *parser) readName() string {
+	line := p.line()
+	start := p.col
+	for p.col < len(line) && !strings.ContainsRune(" \t,[]{}", rune(line[p.col])) {
+		p.col++
+	}
+	return line[start:p.col]
+}
+
+func (p *parser) parseAlias() (*Node, error) {
+	row, col := p.row, p.col
+	name := p.readName()[1:]
+	if name == "" {
+		return nil, &Error{Line: row, Column: col, Message: "alias without a name"}
+	}
+	node := &Node{Kind: AliasNode, Value: name, Line: row, Column: col, EndLine: row, EndColumn: p.col}
+	var err error
+	node.LineComment, err = p.lineComment()
+	return node, err
+}
+
+// Plain scalar, it may continue on the following lines when they are indented deeper than `parent`
+func (p *parser) parsePlain(parent int) (*Node, error) {
+	row, col := p.row, p.col
+	end := plainEnd(p.line(), col)
+	if i := valueIndicator(p.line()[col:end]); i >= 0 {
+		p.col = col + i
+		return nil, p.errorf("unexpected `:` in a plain value, quote the value")
+	}
+	node := &Node{
+		Kind:      ScalarNode,
+		Value:     p.line()[col:end],
+		Line:      row,
+		Column:    col,
+		EndLine:   row,
+		EndColumn: end,
+	}
+	p.col = end
+
+	var err error
+	node.LineComment, err = p.lineComment()
+	if err != nil || node.LineComment != "" {
+		return node, err
+	}
+
+	var value strings.Builder
+	value.WriteString(node.Value)
+	breaks := 0
+	for r := row + 1; r < len(p.lines); r++ {
+		line := p.lines[r]
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(trimmed) == "" {
+			breaks++
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		if indent <= parent || trimmed[0] == '#' || strings.ContainsRune(line[:indent], '\t') || documentMarker(line) || documentEnd(line) {
+			break
+		}
+		// A plain scalar cannot hold a `key: value`, it is a mapping at the wrong indentation
+		if p.row, p.col = r, indent; p.plainKeyIndicator() >= 0 {
+			p.row, p.col = node.EndLine, node.EndColumn
+			break
+		}
+		p.row, p.col = node.EndLine, node.EndColumn
+		if breaks == 0 {
+			value.WriteByte(' ')
+		} else {
+			value.WriteString(strings.Repeat("\n", breaks))
+		}
+		breaks = 0
+		end := plainEnd(line, indent)
+		value.WriteString(line[indent:end])
+		p.row, p.col = r, end
+		node.EndLine, node.EndColumn = r, end
+		node.LineComment, err = p.lineComment()
+		if err != nil || node.LineComment != "" {
+			break
+		}
+	}
+	node.Value = value.String()
+
+	return node, err
+}
+
+// Index of a `:` followed by a space or ending a plain value, -1 if there is none
+func valueIndicator(value string) int {
+	for i := 0; i < len(value); i++ {
+		if value[i] == ':' && (i+1 == len(value) || value[i+1] == ' ' || value[i+1] == '\t') {
+			return i
+		}
+	}
+	return -1
+}
+
+// End of a plain scalar, before a comment and trailing spaces
+func plainEnd(line string, start int) int {
+	end := len(line)
+	for i := start; i < len(line); i++ {
+		if line[i] == '#' && i > start && (line[i-1] == ' ' || line[i-1] == '\t') {
+			end = i
+			break
+		}
+	}
+	return len(strings.TrimRight(line[:end], " \t"))
+}
+
+// Single or double quoted scalar, it may span multiple lines
+func (p *parser) scanQuoted() (*Node, error) {
+	quote := p.peek()
+	node := &Node{Kind: ScalarNode, Style: SingleQuotedStyle, Line: p.row, Column: p.col}
+	if quote == '"' {
+		node.Style = DoubleQuotedStyle
+	}
+	p.col++
+
+	var value strings.Builder
+	for {
+		line := p.line()
+		if p.col >= len(line) {
+			// Line folding, a single break becomes a space and empty lines become breaks
+			content := strings.TrimRight(value.String(), " \t")
+			value.Reset()
+			value.WriteString(content)
+			breaks := 0
+			for {
+				p.row++
+				p.col = 0
+				if p.row >= len(p.lines) {
+					p.row--
+					p.col = len(p.line())
+					return nil, &Error{Line: node.Line, Column: node.Column, Message: "unterminated quoted string"}
+				}
+				if strings.TrimSpace(p.line()) != "" {
+					break
+				}
+				breaks++
+			}
+			if breaks == 0 {
+				value.WriteByte(' ')
+			} else {
+				value.WriteString(strings.Repeat("\n", breaks))
+			}
+			p.skipSpaces()
+			continue
+		}
+
+		c := line[p.col]
+		switch {
+		case c == quote && quote == '\'':
+			if p.col+1 < len(line) && line[p.col+1] == '\'' {
+				value.WriteByte('\'')
+				p.col += 2
+				continue
+			}
+			p.col++
+			node.Value = value.String()
+			node.EndLine, node.EndColumn = p.row, p.col
+			return node, nil
+		case c == quote:
+			p.col++
+			node.Value = value.String()
+			node.EndLine, node.EndColumn = p.row, p.col
+			return node, nil
+		case c == '\\' && quote == '"':
+			if p.col+1 >= len(line) {
+				// Escaped line break, the next line continues without a space
+				p.row++
+				p.col = 0
+				if p.row >= len(p.lines) {
+					p.row--
+					return nil, &Error{Line: node.Line, Column: node.Column, Message: "unterminated quoted string"}
+				}
+				p.skipSpaces()
+				continue
+			}
+			if err := p.escape(&value); err != nil {
+				return nil, err
+			}
+		default:
+			value.WriteByte(c)
+			p.col++
+		}
+	}
+}
+
+var escapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+	'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\",
+	'N': "\u0085", '_': " ", 'L': " ", 'P': " ",
+}
+
+// Escape sequence of a double quoted scalar, the cursor is on the `\`
+func (p *parser) escape(value *strings.Builder) error {
+	line := p.line()
+	c := line[p.col+1]
+	if s, found := escapes[c]; found {
+		value.WriteString(s)
+		p.col += 2
+		return nil
+	}
+
+	size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if size == 0 || p.col+2+size > len(line) {
+		return p.errorf("invalid escape sequence")
+	}
+	code, err := strconv.ParseUint(line[p.col+2:p.col+2+size], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return p.errorf("invalid escape sequence")
+	}
+	value.WriteRune(rune(code))
+	p.col += 2 + size
+	return nil
+}
+
+// Literal `|` or folded `>` block scalar
+func (p *parser) parseBlockScalar(parent int) (*Node, error) {
+	node := &Node{Kind: ScalarNode, Style: LiteralStyle, Line: p.row, Column: p.col}
+	if p.peek() == '>' {
+		node.Style = FoldedStyle
+	}
+	p.col++
+
+	chomp, indent := byte(0), 0
+	for !p.eol() && p.peek() != ' ' && p.peek() != '\t' {
+		switch c := p.peek(); {
+		case c == '-' || c == '+':
+			chomp = c
+		case c >= '1' && c <= '9':
+			indent = int(c - '0')
+		default:
+			return nil, p.errorf("invalid block scalar header")
+		}
+		p.col++
+	}
+	node.EndLine, node.EndColumn = p.row, p.col
+	var err error
+	node.LineComment, err = p.lineComment()
+	if err != nil {
+		return nil, err
+	}
+
+	if indent > 0 {
+		indent += max(parent, 0)
+	}
+	lines := []string{}
+	trailing := 0
+	for r := p.row + 1; r < len(p.lines); r++ {
+		line := p.lines[r]
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			trailing++
+			continue
+		}
+		current := len(line) - len(trimmed)
+		if current == 0 && (documentMarker(line) || documentEnd(line)) {
+			break
+		}
+		if indent == 0 {
+			if current <= parent {
+				break
+			}
+			indent = current
+		}
+		if current < indent {
+			break
+		}
+		for ; trailing > 0; trailing-- {
+			lines = append(lines, "")
+		}
+		lines = append(lines, line[indent:])
+		p.row, p.col = r, len(line)
+		node.EndLine, node.EndColumn = r, len(line)
+	}
+
+	var value strings.Builder
+	if node.Style == LiteralStyle {
+		value.WriteString(strings.Join(lines, "\n"))
+	} else {
+		// Folding, a single break between two lines becomes a space
+		// unless one of them is more indented than the block
+		breaks, previous := 0, ""
+		for _, line := range lines {
+			if line == "" {
+				breaks++
+				continue
+			}
+			if previous == "" {
+				value.WriteString(strings.Repeat("\n", breaks))
+			} else {
+				folded := line[0] != ' ' && line[0] != '\t' && previous[0] != ' ' && previous[0] != '\t'
+				if folded && breaks == 0 {
+					value.WriteByte(' ')
+				} else if folded {
+					value.WriteString(strings.Repeat("\n", breaks))
+				} else {
+					value.WriteString(strings.Repeat("\n", breaks+1))
+				}
+			}
+			breaks, previous = 0, line
+			value.WriteString(line)
+		}
+	}
+	if len(lines) > 0 {
+		switch chomp {
+		case 0:
+			value.WriteByte('\n')
+		case '+':
+			value.WriteString(strings.Repeat("\n", trailing+1))
+		}
+	}
+	node.Value = value.String()
+
+	return node, nil
+}
+
+// Flow collection `[a, b]` or `{a: b}`, it may span multiple lines
+func (p *parser) parseFlow() (*Node, error) {
+	node := &Node{Kind: SequenceNode, Style: FlowStyle, Line: p.row, Column: p.col}
+	closing := byte(']')
+	if p.peek() == '{' {
+		node.Kind = MappingNode
+		closing = '}'
+	}
+	p.col++
+	comments := len(p.flowComments)
+	p.flowDepth++
+	defer func() {
+		node.InnerComment = joinComments(p.flowComments[comments:])
+		if p.flowDepth--; p.flowDepth == 0 {
+			p.flowComments = nil
+		}
+	}()
+
+	for {
+		if err := p.skipFlowSpaces(); err != nil {
+			return nil, err
+		}
+		if p.peek() == closing {
+			p.col++
+			break
+		}
+
+		item, err := p.parseFlowNode(node.Kind == MappingNode)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.skipFlowSpaces(); err != nil {
+			return nil, err
+		}
+		switch {
+		case node.Kind == MappingNode:
+			value, err := p.parseFlowValue(closing)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, item, value)
+		case p.peek() == ':':
+			// A single pair in a sequence is a mapping of its own, `[a: b]` is `[{a: b}]`
+			value, err := p.parseFlowValue(closing)
+			if err != nil {
+				return nil, err
+			}
+			pair := &Node{Kind: MappingNode, Style: FlowStyle, Line: item.Line, Column: item.Column, Content: []*Node{item, value}}
+			pair.EndLine, pair.EndColumn = value.EndLine, value.EndColumn
+			node.Content = append(node.Content, pair)
+		default:
+			node.Content = append(node.Content, item)
+		}
+
+		if err := p.skipFlowSpaces(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.col++
+		case closing:
+		default:
+			return nil, p.errorf("expected `,` or `%c`", closing)
+		}
+	}
+	node.EndLine, node.EndColumn = p.row, p.col
+
+	return node, nil
+}
+
+// Value of a pair in a flow collection, empty when the key has no `:`
+func (p *parser) parseFlowValue(closing byte) (*Node, error) {
+	value := &Node{Kind: ScalarNode, Line: p.row, Column: p.col, EndLine: p.row, EndColumn: p.col}
+	if p.peek() != ':' {
+		return value, nil
+	}
+	p.col++
+	if err := p.skipFlowSpaces(); err != nil {
+		return nil, err
+	}
+	if p.peek() == ',' || p.peek() == closing {
+		return value, nil
+	}
+	return p.parseFlowNode(false)
+}
+
+// Skip spaces, line breaks and comments inside a flow collection
+func (p *parser) skipFlowSpaces() error {
+	for {
+		p.skipSpaces()
+		if !p.eol() && p.peek() != '#' {
+			return nil
+		}
+		if !p.eol() {
+			p.flowComments = append(p.flowComments, strings.TrimRight(p.line()[p.col:], " \t"))
+		}
+		p.row++
+		p.col = 0
+		if p.row >= len(p.lines) {
+			p.row--
+			p.col = len(p.line())
+			return p.errorf("unterminated flow collection")
+		}
+	}
+}
+
+func (p *parser) parseFlowNode(key bool) (*Node, error) {
+	anchor, tag := "", ""
+	anchorRow, anchorCol := 0, 0
+	for p.peek() == '&' || p.peek() == '!' {
+		if p.peek() == '&' {
+			anchorRow, anchorCol = p.row, p.col
+			anchor = p.readName()[1:]
+		} else {
+			tag = p.readName()
+		}
+		if err := p.skipFlowSpaces(); err != nil {
+			return nil, err
+		}
+	}
+
+	var node *Node
+	var err error
+	switch p.peek() {
+	case '[', '{':
+		node, err = p.parseFlow()
+	case '"', '\'':
+		node, err = p.scanQuoted()
+	case '*':
+		row, col := p.row, p.col
+		name := p.readName()[1:]
+		node = &Node{Kind: AliasNode, Value: name, Line: row, Column: col, EndLine: row, EndColumn: p.col}
+	case ',', ']', '}':
+		return nil, p.errorf("expected a value")
+	default:
+		line := p.line()
+		start := p.col
+		for p.col < len(line) {
+			c := line[p.col]
+			if strings.ContainsRune(",[]{}", rune(c)) {
+				break
+			}
+			if c == ':' && (p.col+1 == len(line) || strings.ContainsRune(" \t,[]{}", rune(line[p.col+1]))) {
+				break
+			}
+			if c == '#' && p.col > start && (line[p.col-1] == ' ' || line[p.col-1] == '\t') {
+				break
+			}
+			p.col++
+		}
+		value := strings.TrimRight(line[start:p.col], " \t")
+		node = &Node{
+			Kind:      ScalarNode,
+			Value:     value,
+			Line:      p.row,
+			Column:    start,
+			EndLine:   p.row,
+			EndColumn: start + len(value),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	node.Anchor = anchor
+	node.AnchorLine, node.AnchorColumn = anchorRow, anchorCol
+	node.Tag = tag
+
+	return node, nil
+}
@@ -0,0 +1,232 @@
+package yaml_test
+
+import (
+	"dbwf-ls/yaml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseStructure(t *testing.T) {
+	document := `# workflow
+name: "Shark sightings" # trailing
+description: Weekly
+  report
+tasks:
+  - task_key: ingest
+    depends_on:
+    - task_key: 'other'
+    libraries: [{whl: "a.whl"}, {jar: b.jar}]
+  # second task
+  - task_key: report
+    script: |
+      one
+        two
+`
+	root, err := yaml.Parse(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.Kind != yaml.MappingNode {
+		t.Fatalf("Root kind, expected: %d, got: %d", yaml.MappingNode, root.Kind)
+	}
+	if name := root.Get("name"); name.Value != "Shark sightings" || name.LineComment != "# trailing" {
+		t.Fatalf("Name, expected: Shark sightings # trailing, got: %s %s", name.Value, name.LineComment)
+	}
+	if head := root.Key("name").HeadComment; head != "# workflow" {
+		t.Fatalf("Head comment, expected: # workflow, got: %s", head)
+	}
+	if description := root.Get("description").Value; description != "Weekly report" {
+		t.Fatalf("Multi-line plain scalar, expected: Weekly report, got: %s", description)
+	}
+
+	tasks := root.Get("tasks")
+	if len(tasks.Content) != 2 {
+		t.Fatalf("Tasks, expected: 2, got: %d", len(tasks.Content))
+	}
+	dependency := tasks.Content[0].Get("depends_on").Content[0].Get("task_key")
+	if dependency.Value != "other" || dependency.Line != 7 || dependency.Column != 16 {
+		t.Fatalf("Dependency, expected: other at 7:16, got: %s at %d:%d", dependency.Value, dependency.Line, dependency.Column)
+	}
+	libraries := tasks.Content[0].Get("libraries")
+	if libraries.Style != yaml.FlowStyle || libraries.Content[1].Get("jar").Value != "b.jar" {
+		t.Fatalf("Flow sequence, expected: b.jar, got: %+v", libraries.Content[1])
+	}
+	if head := tasks.Content[1].HeadComment; head != "# second task" {
+		t.Fatalf("Item head comment, expected: # second task, got: %s", head)
+	}
+	if script := tasks.Content[1].Get("script").Value; script != "one\n  two\n" {
+		t.Fatalf("Literal block, expected: %q, got: %q", "one\n  two\n", script)
+	}
+	if tasks.EndLine != 13 {
+		t.Fatalf("Sequence end, expected: 13, got: %d", tasks.EndLine)
+	}
+}
+
+func TestParseScalars(t *testing.T) {
+	tests := []struct {
+		document, expected string
+	}{
+		{`a: "tab\tand \u00e9"`, "tab\tand é"},
+		{`a: 'it''s'`, "it's"},
+		{"a: >-\n  one\n  two\n\n  three\n", "one two\nthree"},
+		{"a: |+\n  keep\n\n", "keep\n\n"},
+		{"a: plain # comment", "plain"},
+		{"a: http://example.com/#anchor", "http://example.com/#anchor"},
+	}
+	for _, test := range tests {
+		root, err := yaml.Parse(test.document)
+		if err != nil {
+			t.Fatalf("%q: %s", test.document, err)
+		}
+		if actual := root.Get("a").Value; actual != test.expected {
+			t.Fatalf("Expected: %q, Actual: %q", test.expected, actual)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		document     string
+		line, column int
+	}{
+		{"a: 1\n   b: 2\n", 1, 3},
+		{"a:\n\t- b\n", 1, 1},
+		{"a: \"open\n", 0, 3},
+		{"a: [1, 2\n", 0, 8},
+		{"a: 1\n---\nb: 2\n", 1, 0},
+		{"a: 1\n...\nb: 2\n", 2, 0},
+		{"key: a: b\n", 0, 6},
+		{"key: a:\n", 0, 6},
+		{"- a: b: c\n", 0, 6},
+		{"a:\n  b: 1\n c: 2\n", 2, 1},
+		{"a: [1, 2] 3\n", 0, 10},
+		{"a: {b: 1\n", 0, 8},
+		{"a: [1]]\n", 0, 6},
+		{"a: 'open\n\n", 0, 3},
+		{"a: \"\\q\"\n", 0, 4},
+		{"a: |x\n  b\n", 0, 4},
+		{"a: &\n", 0, 3},
+		{"a: *\n", 0, 3},
+		{"? a\n: b\n", 0, 0},
+		{"a:\n  - b\n  c: d\n", 2, 2},
+		{"a: 1\nb\n", 1, 0},
+	}
+	for _, test := range tests {
+		_, err := yaml.Parse(test.document)
+		var syntaxError *yaml.Error
+		if !errors.As(err, &syntaxError) {
+			t.Fatalf("%q, expected a syntax error, got: %v", test.document, err)
+		}
+		if syntaxError.Line != test.line || syntaxError.Column != test.column {
+			t.Fatalf("%q, expected: %d:%d, got: %d:%d %s", test.document, test.line, test.column, syntaxError.Line, syntaxError.Column, syntaxError.Message)
+		}
+	}
+}
+
+func TestParseDocumentEnd(t *testing.T) {
+	tests := []struct {
+		document, expected string
+	}{
+		{"a: 1\n...\n", "1"},
+		{"---\na: 1\n... # end\n", "1"},
+		{"a: 1\n...\n# after the end\n", "1"},
+		{"a: |\n  1\n...\n", "1\n"},
+		{"a: [1]\n...\n", "[1]"},
+	}
+	for _, test := range tests {
+		root, err := yaml.Parse(test.document)
+		if err != nil {
+			t.Fatalf("%q: %s", test.document, err)
+		}
+		a := root.Get("a")
+		actual := a.Value
+		if a.Kind != yaml.ScalarNode {
+			actual = strings.TrimSpace(yaml.Encode(a))
+		}
+		if actual != test.expected {
+			t.Fatalf("%q, expected: %q, actual: %q", test.document, test.expected, actual)
+		}
+	}
+}
+
+func TestParsePlainValues(t *testing.T) {
+	tests := []struct {
+		document, expected string
+	}{
+		{"a: b:c", "b:c"},
+		{"a: http://example.com", "http://example.com"},
+		{"a: b#c", "b#c"},
+		{"a: -1", "-1"},
+		{"a: b\n  :c", "b :c"},
+		{"- a:b", "a:b"},
+	}
+	for _, test := range tests {
+		root, err := yaml.Parse(test.document)
+		if err != nil {
+			t.Fatalf("%q: %s", test.document, err)
+		}
+		node := root
+		if root.Kind == yaml.MappingNode {
+			node = root.Get("a")
+		} else {
+			node = root.Content[0]
+		}
+		if node.Value != test.expected {
+			t.Fatalf("%q, expected: %q, actual: %q", test.document, test.expected, node.Value)
+		}
+	}
+}
+
+func TestParseFlowPairs(t *testing.T) {
+	tests := []struct {
+		document string
+		pairs    [][2]string // key and value of each item, items that aren't pairs have no key
+	}{
+		{"a: [b: 1]", [][2]string{{"b", "1"}}},
+		{"a: [b: 1, c, d: x:y]", [][2]string{{"b", "1"}, {"", "c"}, {"d", "x:y"}}},
+		{"a: [\"b\": 1, 'c':2]", [][2]string{{"b", "1"}, {"c", "2"}}},
+		{"a: [b:, c: ]", [][2]string{{"b", ""}, {"c", ""}}},
+		{"a: [http://x, [b: 1]]", [][2]string{{"", "http://x"}, {"", ""}}},
+	}
+	for _, test := range tests {
+		root, err := yaml.Parse(test.document)
+		if err != nil {
+			t.Fatalf("%q: %s", test.document, err)
+		}
+		items := root.Get("a").Content
+		if len(items) != len(test.pairs) {
+			t.Fatalf("%q, expected: %d items, actual: %d", test.document, len(test.pairs), len(items))
+		}
+		for i, item := range items {
+			key, value := "", item.Value
+			if item.Kind == yaml.MappingNode {
+				if len(item.Content) != 2 || item.Style != yaml.FlowStyle {
+					t.Fatalf("%q, expected a single pair, actual: %+v", test.document, item)
+				}
+				key, value = item.Content[0].Value, item.Content[1].Value
+			}
+			if key != test.pairs[i][0] || value != test.pairs[i][1] {
+				t.Fatalf("%q, expected: %s: %s, actual: %s: %s", test.document, test.pairs[i][0], test.pairs[i][1], key, value)
+			}
+		}
+	}
+}
+
+func TestParseFlowComments(t *testing.T) {
+	root, err := yaml.Parse("a: [b, # first\n  {c: d, # second\n  }]\ne: [f] # after\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := root.Get("a")
+	if expected := "# first\n# second"; a.InnerComment != expected {
+		t.Fatalf("expected: %q, actual: %q", expected, a.InnerComment)
+	}
+	if expected := "# second"; a.Content[1].InnerComment != expected {
+		t.Fatalf("expected: %q, actual: %q", expected, a.Content[1].InnerComment)
+	}
+	if e := root.Get("e"); e.InnerComment != "" || e.LineComment != "# after" {
+		t.Fatalf("expected: no inner comment and # after, actual: %q %q", e.InnerComment, e.LineComment)
+	}
+}
@@ -0,0 +1,65 @@
+package yaml
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	intPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	octPattern   = regexp.MustCompile(`^0o[0-7]+$`)
+	hexPattern   = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	floatPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// Value of a scalar following the YAML 1.2 core schema
+// Quoted and block scalars are strings, plain ones can be null, bool, int64 or float64
+func Resolve(n *Node) any {
+	if n.Kind != ScalarNode {
+		return nil
+	}
+	if n.Style != PlainStyle || n.Tag == "!!str" {
+		return n.Value
+	}
+	if n.IsNull() {
+		return nil
+	}
+
+	value := n.Value
+	switch value {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1)
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1)
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN()
+	}
+
+	switch {
+	case intPattern.MatchString(value):
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case octPattern.MatchString(value):
+		if i, err := strconv.ParseInt(value[2:], 8, 64); err == nil {
+			return i
+		}
+	case hexPattern.MatchString(value):
+		if i, err := strconv.ParseInt(value[2:], 16, 64); err == nil {
+			return i
+		}
+	}
+	if floatPattern.MatchString(value) && strings.ContainsAny(value, ".eE") {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}